@@ -53,6 +53,15 @@ func (i *arrayFlags) Set(value string) error {
 // ReadFlags command-line flags into app config.
 func ReadFlags() config.Config {
 	files := arrayFlags{}
+	finalizers := arrayFlags{}
+	subcharts := arrayFlags{}
+	labels, annotations := arrayFlags{}, arrayFlags{}
+	imageArch := arrayFlags{}
+	serviceAnnotations := arrayFlags{}
+	setValues, setStringValues := arrayFlags{}, arrayFlags{}
+	artifactHubChanges := arrayFlags{}
+	correlationLabels := arrayFlags{}
+	requiredValues := arrayFlags{}
 	result := config.Config{}
 	var h, help, version, crd bool
 	flag.BoolVar(&h, "h", false, "Print help. Example: helmify -h")
@@ -60,13 +69,47 @@ func ReadFlags() config.Config {
 	flag.BoolVar(&version, "version", false, "Print helmify version. Example: helmify -version")
 	flag.BoolVar(&result.Verbose, "v", false, "Enable verbose output (print WARN & INFO). Example: helmify -v")
 	flag.BoolVar(&result.VeryVerbose, "vv", false, "Enable very verbose output. Same as verbose but with DEBUG. Example: helmify -vv")
+	flag.StringVar(&result.LogLevel, "log-level", "", "Log level: debug, info, warn or error. Overrides -v and -vv. Example: helmify -log-level=debug")
+	flag.StringVar(&result.LogFormat, "log-format", config.LogFormatText, "Log output format: text or json. Example: helmify -log-format=json")
 	flag.BoolVar(&crd, "crd-dir", false, "Enable crd install into 'crds' directory.\nWarning: CRDs placed in 'crds' directory will not be templated by Helm.\nSee https://helm.sh/docs/chart_best_practices/custom_resource_definitions/#some-caveats-and-explanations\nExample: helmify -crd-dir")
 	flag.BoolVar(&result.ImagePullSecrets, "image-pull-secrets", false, "Allows the user to use existing secrets as imagePullSecrets in values.yaml")
 	flag.BoolVar(&result.GenerateDefaults, "generate-defaults", false, "Allows the user to add empty placeholders for tipical customization options in values.yaml. Currently covers: topology constraints, node selectors, tolerances")
 	flag.BoolVar(&result.CertManagerAsSubchart, "cert-manager-as-subchart", false, "Allows the user to add cert-manager as a subchart")
 	flag.StringVar(&result.CertManagerVersion, "cert-manager-version", "v1.12.2", "Allows the user to specify cert-manager subchart version. Only useful with cert-manager-as-subchart.")
+	flag.StringVar(&result.ChartType, "chart-type", config.ChartTypeApplication, "Chart.yaml type: application or library. Library chart has no appVersion and exposes resources as named templates. Example: helmify -chart-type=library")
 	flag.BoolVar(&result.FilesRecursively, "r", false, "Scan dirs from -f option recursively")
+	flag.BoolVar(&result.Strict, "strict", false, "Fail if some input resources are not supported and can only be copied to the chart without templating. Example: helmify -strict")
+	flag.BoolVar(&result.FailOnWarning, "fail-on-warning", false, "Fail chart generation with non-zero exit code if any warning is reported, e.g. dangling reference or unreadable input file. Warnings are collected regardless of the log level. Chart is not written. Example: helmify -fail-on-warning")
 	flag.Var(&files, "f", "File or directory containing k8s manifests")
+	flag.BoolVar(&result.ConvertReplicationControllers, "rc-to-deployment", false, "Convert legacy ReplicationController resources to Deployment. Example: helmify -rc-to-deployment")
+	flag.StringVar(&result.IngressDomain, "ingress-domain", "", "Base domain of Ingress hosts moved to shared global.domain value. Hosts not in the domain are kept as they are. Example: helmify -ingress-domain=example.com")
+	flag.BoolVar(&result.IngressBackendPortNames, "ingress-port-names", false, "Rewrite numeric Ingress backend ports to port names of chart Services. Example: helmify -ingress-port-names")
+	flag.BoolVar(&result.IngressExtraAnnotations, "ingress-extra-annotations", false, "Merge Ingress <name>.ingress.extraAnnotations value on top of default annotations from values. Example: helmify -ingress-extra-annotations")
+	flag.StringVar(&result.ReferenceReport, "reference-report", "", "Write JSON report of ConfigMaps, Secrets, PersistentVolumeClaims and ServiceAccounts referenced by chart workloads to given file. Example: helmify -reference-report=refs.json")
+	flag.IntVar(&result.ValuesIndent, "values-indent", 0, "Indentation of values.yaml nested mappings, 2 to 9 spaces. Example: helmify -values-indent=4")
+	flag.BoolVar(&result.ValuesIndentSequences, "values-indent-sequences", false, "Indent values.yaml sequence items under parent key. Example: helmify -values-indent-sequences")
+	flag.StringVar(&result.ValuesQuote, "values-quote", "", "Quote all values.yaml string values: double or single. Example: helmify -values-quote=double")
+	flag.StringVar(&result.ValuesLayout, "reuse-values-structure", "", "Reference values.yaml file. Generated values are placed into matching keys of its structure, unmatched values keep default keys. Example: helmify -reuse-values-structure=./mychart/values.yaml")
+	flag.StringVar(&result.EnvLabel, "env-label", "", "Label key marking environment of input resources. Environment variants of the same resource are templated once and their differences are written to values-<env>.yaml. Example: helmify -env-label=env")
+	flag.Var(&imageArch, "image-arch", "CPU architecture of image repository in repository=arch format. Pods with all images of the same known architecture get kubernetes.io/arch nodeSelector default. Can be repeated. Example: helmify -image-arch=nginx=amd64")
+	flag.BoolVar(&result.TolerationFields, "toleration-fields", false, "Move key, operator, value, effect and tolerationSeconds of every pod toleration to separate values instead of the whole tolerations list. Example: helmify -toleration-fields")
+	flag.BoolVar(&result.RBACRulesValues, "rbac-rules-values", false, "Move Role and ClusterRole rules to <name>.role.rules and <name>.clusterRole.rules values to allow permissions adjustment. Example: helmify -rbac-rules-values")
+	flag.StringVar(&result.BlueGreenLabel, "blue-green-label", "", "Pod label key of blue-green color. Service selectors select pods with the label set to activeColor value. Example: helmify -blue-green-label=color")
+	flag.Var(&serviceAnnotations, "service-annotation-value", "Regular expression of Service annotation keys moved to <service>.annotations values. Can be repeated. Example: helmify -service-annotation-value='aws-load-balancer-ssl-cert$'")
+	flag.BoolVar(&result.CustomResourceValues, "cr-values", false, "Move spec fields of custom resources to values according to OpenAPI schema of CRD from the input and write values.schema.json. Example: helmify -cr-values")
+	flag.Var(&correlationLabels, "correlation-label", "Label key identifying workload pods, used to match a Service to a workload when its selector matches pods of several workloads. Can be repeated. Defaults to app and app.kubernetes.io/name. Example: helmify -correlation-label=component")
+	flag.Var(&requiredValues, "required-value", "Values path of ConfigMap data without safe default. The value defaults to empty and chart rendering fails until it is set. Can be repeated. Example: helmify -required-value=myAppConfig.dbHost")
+	flag.BoolVar(&result.MergeRBACRoles, "merge-rbac-roles", false, "Merge Roles and ClusterRoles with identical rules into one and rewrite bindings to reference it. Example: helmify -merge-rbac-roles")
+	flag.Var(&setValues, "set", "Set default in values.yaml. Same format as helm '--set'. Can be repeated. Example: helmify -set=myApp.replicas=2")
+	flag.Var(&setStringValues, "set-string", "Set string default in values.yaml. Same format as helm '--set-string'. Can be repeated. Example: helmify -set-string=myApp.app.image.tag=1.20")
+	flag.BoolVar(&result.ExtraTemplates, "extra-templates", false, "Create templates/extra directory for hand-written templates. Helmify never overwrites its content. Example: helmify -extra-templates")
+	flag.BoolVar(&result.MergeInto, "merge-into", false, "Update existing chart in place: add templates of new resources and merge generated values into existing values.yaml. Unchanged files are not rewritten. Example: helmify -merge-into mychart")
+	flag.BoolVar(&result.ArtifactHubImages, "artifacthub-images", false, "Set artifacthub.io/images Chart.yaml annotation listing container images from the input. Example: helmify -artifacthub-images")
+	flag.Var(&artifactHubChanges, "artifacthub-change", "Change in kind=description format listed in artifacthub.io/changes Chart.yaml annotation. Kind is one of added, changed, deprecated, removed, fixed, security. Can be repeated. Example: helmify -artifacthub-change='added=Redis cache'")
+	flag.Var(&subcharts, "subchart", "Local subchart from <chart>/charts/<name> directory to add to chart dependencies with file:// repository. Can be repeated. Example: helmify -subchart=database")
+	flag.Var(&labels, "label", "Label in key=value format added to all generated resources. Can be repeated. Example: helmify -label=generated-by=helmify")
+	flag.Var(&annotations, "annotation", "Annotation in key=value format added to all generated resources. Can be repeated. Example: helmify -annotation=example.com/build-id=42")
+	flag.Var(&finalizers, "preserve-finalizer", "Finalizer to keep in chart objects. All other finalizers are stripped. Can be repeated. Example: helmify -preserve-finalizer=kubernetes.io/pvc-protection")
 
 	flag.Parse()
 	if h || help {
@@ -87,5 +130,33 @@ func ReadFlags() config.Config {
 		result.Crd = crd
 	}
 	result.Files = files
+	result.PreserveFinalizers = finalizers
+	result.Labels = keyValues("label", labels)
+	result.Annotations = keyValues("annotation", annotations)
+	result.ImageArch = keyValues("image-arch", imageArch)
+	result.ServiceAnnotationValues = serviceAnnotations
+	result.Subcharts = subcharts
+	result.SetValues = setValues
+	result.SetStringValues = setStringValues
+	result.ArtifactHubChanges = artifactHubChanges
+	result.CorrelationLabels = correlationLabels
+	result.RequiredValues = requiredValues
 	return result
 }
+
+// keyValues parses key=value flag values into map. Exits on invalid format.
+func keyValues(flagName string, values []string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	res := make(map[string]string, len(values))
+	for _, kv := range values {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			fmt.Printf("invalid -%s value %q: expected key=value\n", flagName, kv)
+			os.Exit(1)
+		}
+		res[k] = v
+	}
+	return res
+}
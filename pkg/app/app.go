@@ -12,6 +12,7 @@ import (
 	"github.com/arttor/helmify/pkg/processor/poddisruptionbudget"
 	"github.com/arttor/helmify/pkg/processor/statefulset"
 
+	"github.com/arttor/helmify/pkg/warning"
 	"github.com/sirupsen/logrus"
 
 	"github.com/arttor/helmify/pkg/config"
@@ -20,9 +21,14 @@ import (
 	"github.com/arttor/helmify/pkg/processor"
 	"github.com/arttor/helmify/pkg/processor/configmap"
 	"github.com/arttor/helmify/pkg/processor/crd"
+	"github.com/arttor/helmify/pkg/processor/customresource"
 	"github.com/arttor/helmify/pkg/processor/daemonset"
 	"github.com/arttor/helmify/pkg/processor/deployment"
+	"github.com/arttor/helmify/pkg/processor/hpa"
+	"github.com/arttor/helmify/pkg/processor/monitoring"
+	"github.com/arttor/helmify/pkg/processor/quota"
 	"github.com/arttor/helmify/pkg/processor/rbac"
+	"github.com/arttor/helmify/pkg/processor/replicationcontroller"
 	"github.com/arttor/helmify/pkg/processor/secret"
 	"github.com/arttor/helmify/pkg/processor/service"
 	"github.com/arttor/helmify/pkg/processor/storage"
@@ -52,7 +58,9 @@ func Start(stdin io.Reader, config config.Config) error {
 		daemonset.New(),
 		deployment.New(),
 		statefulset.New(),
+		replicationcontroller.New(),
 		storage.New(),
+		storage.NewPV(),
 		service.New(),
 		service.NewIngress(),
 		rbac.ClusterRoleBinding(),
@@ -67,7 +75,17 @@ func Start(stdin io.Reader, config config.Config) error {
 		job.NewCron(),
 		job.NewJob(),
 		poddisruptionbudget.New(),
+		monitoring.New(),
+		hpa.New(),
+		quota.New(),
+		quota.NewLimitRange(),
+		customresource.New(),
 	).WithDefaultProcessor(processor.Default())
+	if config.FailOnWarning {
+		warnings, stop := warning.Collect()
+		defer stop()
+		appCtx = appCtx.WithWarnings(warnings)
+	}
 	if len(config.Files) != 0 {
 		file.Walk(config.Files, config.FilesRecursively, func(filename string, fileReader io.Reader) {
 			objects := decoder.Decode(ctx.Done(), fileReader)
@@ -85,12 +103,18 @@ func Start(stdin io.Reader, config config.Config) error {
 	return appCtx.CreateHelm(ctx.Done())
 }
 
-func setLogLevel(config config.Config) {
+func setLogLevel(conf config.Config) {
 	logrus.SetLevel(logrus.ErrorLevel)
-	if config.Verbose {
+	if conf.Verbose {
 		logrus.SetLevel(logrus.InfoLevel)
 	}
-	if config.VeryVerbose {
+	if conf.VeryVerbose {
 		logrus.SetLevel(logrus.DebugLevel)
 	}
+	if level, err := logrus.ParseLevel(conf.LogLevel); err == nil {
+		logrus.SetLevel(level)
+	}
+	if conf.LogFormat == config.LogFormatJSON {
+		logrus.SetFormatter(&logrus.JSONFormatter{})
+	}
 }
@@ -2,12 +2,27 @@ package app
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
 )
 
 const (
@@ -58,3 +73,2462 @@ func TestApp(t *testing.T) {
 		assert.NoError(t, err)
 	}
 }
+
+func TestGlobalPodAnnotations(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+      annotations:
+        prometheus.io/scrape: "true"
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:15`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"global": map[string]interface{}{
+			"podAnnotations": map[string]interface{}{"sidecar.istio.io/inject": "true"},
+		},
+	})
+	depl := manifests[appChartName+"/templates/deployment.yaml"]
+	assert.Contains(t, depl, "sidecar.istio.io/inject: \"true\"")
+	assert.Contains(t, depl, "prometheus.io/scrape: \"true\"")
+	sts := manifests[appChartName+"/templates/statefulset.yaml"]
+	assert.Contains(t, sts, "sidecar.istio.io/inject: \"true\"")
+	assert.NotContains(t, sts, "prometheus.io/scrape")
+}
+
+func TestIngressBackendPorts(t *testing.T) {
+	const input = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-ingress
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /api
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-api
+            port:
+              number: 80
+      - path: /web
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-web
+            port:
+              name: http`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	ing := manifests[appChartName+"/templates/my-app-ingress.yaml"]
+	assert.Contains(t, ing, `port: {"number":80}`)
+	assert.Contains(t, ing, `port: {"name":"http"}`)
+}
+
+func TestIngressBackendSharedPortName(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: web
+  ports:
+  - name: http
+    port: 80
+    targetPort: 8080
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-ingress
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-web
+            port:
+              name: http`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"portNames": map[string]interface{}{"http": "web"},
+	})
+	var svc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web.yaml"]), &svc))
+	assert.Equal(t, "web", svc.Spec.Ports[0].Name)
+	var ing networkingv1.Ingress
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/ingress.yaml"]), &ing))
+	assert.Equal(t, "web", ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port.Name)
+}
+
+func TestIngressClassName(t *testing.T) {
+	const input = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-ingress
+spec:
+  defaultBackend:
+    service:
+      name: my-app-web
+      port:
+        number: 80`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	chartPath := filepath.Join(chartDir, appChartName)
+	ing := renderChart(t, chartPath, nil)[appChartName+"/templates/my-app-ingress.yaml"]
+	assert.NotContains(t, ing, "ingressClassName")
+
+	ing = renderChart(t, chartPath, map[string]interface{}{
+		"myAppIngress": map[string]interface{}{
+			"ingress": map[string]interface{}{"className": "nginx"},
+		},
+	})[appChartName+"/templates/my-app-ingress.yaml"]
+	assert.Contains(t, ing, "  ingressClassName: nginx")
+}
+
+func TestInChartImagePullSecret(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-regcred
+type: kubernetes.io/dockerconfigjson
+data:
+  .dockerconfigjson: e30=
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      imagePullSecrets:
+      - name: my-app-regcred
+      - name: external-regcred
+      containers:
+      - name: app
+        image: my-app:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"regcred": map[string]interface{}{"dockerconfigjson": "{}"},
+	})
+	assert.Contains(t, manifests[appChartName+"/templates/regcred.yaml"], "name: test-test-app-regcred")
+	depl := manifests[appChartName+"/templates/deployment.yaml"]
+	assert.Contains(t, depl, "- name: test-test-app-regcred")
+	assert.Contains(t, depl, "- name: external-regcred")
+}
+
+func TestSetStringValues(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{
+		ChartName:       appChartName,
+		ChartDir:        chartDir,
+		SetValues:       []string{"deployment.replicas=3"},
+		SetStringValues: []string{"deployment.app.image.tag=1.20"},
+	})
+	assert.NoError(t, err)
+
+	values, err := os.ReadFile(filepath.Join(chartDir, appChartName, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(values), `tag: "1.20"`)
+	assert.Contains(t, string(values), "replicas: 3")
+}
+
+func TestCommonLabelsAndAnnotations(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-svc
+  labels:
+    app.kubernetes.io/part-of: shop
+  annotations:
+    example.com/owner: web
+spec:
+  selector:
+    app: my-app
+  ports:
+  - port: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  key: value
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	chartPath := filepath.Join(chartDir, appChartName)
+	manifests := renderChart(t, chartPath, nil)
+	assert.NotContains(t, manifests[appChartName+"/templates/config.yaml"], "annotations:")
+
+	manifests = renderChart(t, chartPath, map[string]interface{}{
+		"commonLabels":      map[string]interface{}{"team": "platform"},
+		"commonAnnotations": map[string]interface{}{"example.com/contact": "ops"},
+	})
+	for _, name := range []string{"svc.yaml", "config.yaml", "deployment.yaml"} {
+		manifest := manifests[appChartName+"/templates/"+name]
+		assert.Contains(t, manifest, "team: platform", name)
+		assert.Contains(t, manifest, "example.com/contact: ops", name)
+	}
+	assert.Contains(t, manifests[appChartName+"/templates/svc.yaml"], "example.com/owner: web")
+
+	// keys set in several label sources are rendered once
+	manifests = renderChart(t, chartPath, map[string]interface{}{
+		"commonLabels": map[string]interface{}{
+			"app.kubernetes.io/part-of": "platform",
+			"app.kubernetes.io/name":    "other",
+			"team":                      "platform",
+		},
+	})
+	assert.Equal(t, 1, strings.Count(manifests[appChartName+"/templates/svc.yaml"], "app.kubernetes.io/part-of:"))
+	var svc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/svc.yaml"]), &svc))
+	assert.Equal(t, "shop", svc.Labels["app.kubernetes.io/part-of"])
+	assert.Equal(t, appChartName, svc.Labels["app.kubernetes.io/name"])
+	assert.Equal(t, "platform", svc.Labels["team"])
+	var cm corev1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/config.yaml"]), &cm))
+	assert.Equal(t, "platform", cm.Labels["app.kubernetes.io/part-of"])
+}
+
+func TestLocalSubchart(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  key: value`
+	chartDir := t.TempDir()
+	subchartDir := filepath.Join(chartDir, appChartName, "charts", "database")
+	assert.NoError(t, os.MkdirAll(subchartDir, 0750))
+	assert.NoError(t, os.WriteFile(filepath.Join(subchartDir, "Chart.yaml"), []byte("apiVersion: v2\nname: database\nversion: 1.2.3\n"), 0640))
+
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, Subcharts: []string{"missing"}})
+	assert.ErrorContains(t, err, "unable to read subchart missing")
+
+	err = Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, Subcharts: []string{"database"}})
+	assert.NoError(t, err)
+	chartFile, err := os.ReadFile(filepath.Join(chartDir, appChartName, "Chart.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(chartFile), `repository: "file://charts/database"`)
+
+	chrt, err := loader.Load(filepath.Join(chartDir, appChartName))
+	assert.NoError(t, err)
+	assert.NoError(t, action.CheckDependencies(chrt, chrt.Metadata.Dependencies))
+}
+
+func TestContainerResources(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: nginx:1.25
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-worker
+spec:
+  selector:
+    matchLabels:
+      app: worker
+  template:
+    metadata:
+      labels:
+        app: worker
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1
+        resources:
+          requests:
+            cpu: 100m
+          limits:
+            cpu: 200m`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"api":    map[string]interface{}{"api": map[string]interface{}{"resources": map[string]interface{}{"limits": map[string]interface{}{}}}},
+		"worker": map[string]interface{}{"worker": map[string]interface{}{"resources": map[string]interface{}{"limits": nil}}},
+	})
+	manifest := manifests[appChartName+"/templates/deployment.yaml"]
+	assert.Equal(t, 2, strings.Count(manifest, "resources:\n          requests:\n            cpu: 100m"))
+	assert.NotContains(t, manifest, "limits")
+}
+
+func TestInjectedMetadata(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  key: value
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: my-app-sa
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-api
+  labels:
+    app: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{
+		ChartName:   appChartName,
+		ChartDir:    chartDir,
+		Labels:      map[string]string{"generated-by": "helmify"},
+		Annotations: map[string]string{"example.com/build-id": "42"},
+	})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	for _, name := range []string{"config.yaml", "serviceaccount.yaml", "deployment.yaml"} {
+		manifest := manifests[appChartName+"/templates/"+name]
+		assert.Contains(t, manifest, "generated-by: helmify", name)
+		assert.Contains(t, manifest, `example.com/build-id: "42"`, name)
+	}
+	assert.Contains(t, manifests[appChartName+"/templates/deployment.yaml"], "app: api")
+}
+
+// renderChart renders chart templates with given values overrides. Returns rendered manifests by template path.
+func renderChart(t *testing.T, chartPath string, vals map[string]interface{}) map[string]string {
+	t.Helper()
+	chrt, err := loader.Load(chartPath)
+	assert.NoError(t, err)
+	renderVals, err := chartutil.ToRenderValues(chrt, vals, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, chartutil.DefaultCapabilities)
+	assert.NoError(t, err)
+	manifests, err := engine.Render(chrt, renderVals)
+	assert.NoError(t, err)
+	return manifests
+}
+
+func TestExtraEnvFrom(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      initContainers:
+      - name: migrate
+        image: my-app:v1
+        envFrom:
+        - prefix: APP_
+          configMapRef:
+            name: my-app-config
+      containers:
+      - name: app
+        image: my-app:v1
+        envFrom:
+        - configMapRef:
+            name: my-app-config
+      - name: sidecar
+        image: sidecar:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	values, err := os.ReadFile(filepath.Join(chartDir, appChartName, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(values), "extraEnvFrom: []")
+
+	render := func(vals map[string]interface{}) appsv1.Deployment {
+		manifests := renderChart(t, filepath.Join(chartDir, appChartName), vals)
+		var depl appsv1.Deployment
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+		return depl
+	}
+
+	configRef := corev1.EnvFromSource{ConfigMapRef: &corev1.ConfigMapEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "test-test-app-config"}}}
+	prefixedConfig := corev1.EnvFromSource{Prefix: "APP_", ConfigMapRef: configRef.ConfigMapRef}
+	podSpec := render(nil).Spec.Template.Spec
+	assert.Equal(t, []corev1.EnvFromSource{configRef}, podSpec.Containers[0].EnvFrom)
+	assert.Empty(t, podSpec.Containers[1].EnvFrom)
+	assert.Equal(t, []corev1.EnvFromSource{prefixedConfig}, podSpec.InitContainers[0].EnvFrom)
+
+	podSpec = render(map[string]interface{}{
+		"deployment": map[string]interface{}{
+			"extraEnvFrom": []interface{}{
+				map[string]interface{}{"secretRef": map[string]interface{}{"name": "extra"}},
+			},
+		},
+	}).Spec.Template.Spec
+	extra := corev1.EnvFromSource{SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "extra"}}}
+	assert.Equal(t, []corev1.EnvFromSource{configRef, extra}, podSpec.Containers[0].EnvFrom)
+	assert.Equal(t, []corev1.EnvFromSource{extra}, podSpec.Containers[1].EnvFrom)
+	assert.Equal(t, []corev1.EnvFromSource{prefixedConfig, extra}, podSpec.InitContainers[0].EnvFrom)
+}
+
+func TestServicePortsOverride(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: my-app
+  ports:
+  - name: http
+    port: 80
+    targetPort: http
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+        ports:
+        - name: http
+          containerPort: 8080`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	render := func(vals map[string]interface{}) corev1.Service {
+		manifests := renderChart(t, filepath.Join(chartDir, appChartName), vals)
+		var svc corev1.Service
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web.yaml"]), &svc))
+		return svc
+	}
+
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+	}, render(nil).Spec.Ports)
+
+	ports := render(map[string]interface{}{
+		"web": map[string]interface{}{
+			"ports": []interface{}{
+				map[string]interface{}{"name": "http", "port": 80, "targetPort": "http"},
+				map[string]interface{}{"name": "metrics", "port": 9090, "targetPort": 9090},
+			},
+		},
+	}).Spec.Ports
+	assert.Equal(t, []corev1.ServicePort{
+		{Name: "http", Port: 80, TargetPort: intstr.FromString("http")},
+		{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+	}, ports)
+}
+
+func TestLibraryChart(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, ChartType: config.ChartTypeLibrary})
+	assert.NoError(t, err)
+	libPath := filepath.Join(chartDir, appChartName)
+
+	chrt, err := loader.Load(libPath)
+	assert.NoError(t, err)
+	assert.Equal(t, config.ChartTypeLibrary, chrt.Metadata.Type)
+	assert.Empty(t, chrt.Metadata.AppVersion)
+	assert.NoDirExists(t, filepath.Join(libPath, "crds"))
+	// resources are exposed as named templates instead of manifests
+	assert.NoFileExists(t, filepath.Join(libPath, "templates", "my-app-config.yaml"))
+	assert.FileExists(t, filepath.Join(libPath, "templates", "_my-app-config.tpl"))
+	assert.Empty(t, renderChart(t, libPath, nil))
+
+	// including chart renders the resource from its own values
+	parentPath := filepath.Join(t.TempDir(), "parent")
+	assert.NoError(t, os.MkdirAll(filepath.Join(parentPath, "templates"), 0750))
+	assert.NoError(t, os.MkdirAll(filepath.Join(parentPath, "charts"), 0750))
+	assert.NoError(t, os.Rename(libPath, filepath.Join(parentPath, "charts", appChartName)))
+	parentChart := "apiVersion: v2\nname: parent\nversion: 0.1.0\ndependencies:\n- name: " + appChartName + "\n  version: 0.1.0\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(parentPath, "Chart.yaml"), []byte(parentChart), 0600))
+	include := `{{ include "` + appChartName + `.my-app-config" . }}`
+	assert.NoError(t, os.WriteFile(filepath.Join(parentPath, "templates", "config.yaml"), []byte(include), 0600))
+	parentValues := "myAppConfig:\n  logLevel: info\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(parentPath, "values.yaml"), []byte(parentValues), 0600))
+	manifests := renderChart(t, parentPath, nil)
+	var cm corev1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests["parent/templates/config.yaml"]), &cm))
+	assert.Equal(t, "info", cm.Data["LOG_LEVEL"])
+
+	err = Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: t.TempDir(), ChartType: config.ChartTypeLibrary, Crd: true})
+	assert.Error(t, err)
+}
+
+func TestPodLabelsOverride(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+        tier: web
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-postgres
+spec:
+  serviceName: my-app-postgres
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+        tier: storage
+    spec:
+      containers:
+      - name: db
+        image: postgres:16`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"deployment": map[string]interface{}{
+			"podLabels": map[string]interface{}{"tier": "api", "app": "other"},
+		},
+		"postgres": map[string]interface{}{
+			"podLabels": map[string]interface{}{"tier": "cache", "app": "other"},
+		},
+	})
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	assert.Equal(t, map[string]string{
+		"app":                        "my-app",
+		"app.kubernetes.io/name":     appChartName,
+		"app.kubernetes.io/instance": "test",
+	}, depl.Spec.Selector.MatchLabels)
+	assert.Equal(t, map[string]string{
+		"app":                        "my-app",
+		"tier":                       "api",
+		"app.kubernetes.io/name":     appChartName,
+		"app.kubernetes.io/instance": "test",
+	}, depl.Spec.Template.Labels)
+
+	// selector labels of statefulset pods are kept when pod labels are overridden
+	var sts appsv1.StatefulSet
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/statefulset.yaml"]), &sts))
+	assert.Equal(t, map[string]string{"app": "db"}, sts.Spec.Selector.MatchLabels)
+	assert.Equal(t, map[string]string{
+		"app":                        "db",
+		"tier":                       "cache",
+		"app.kubernetes.io/name":     appChartName,
+		"app.kubernetes.io/instance": "test",
+	}, sts.Spec.Template.Labels)
+}
+
+func TestServiceSelectorMatchesWorkload(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: my-app-db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:16
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: web
+    tier: frontend
+  ports:
+  - port: 80
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-db
+spec:
+  selector:
+    app: db
+  ports:
+  - port: 5432`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"web": map[string]interface{}{
+			"podLabels": map[string]interface{}{"tier": "api"},
+		},
+	})
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	var webSvc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web.yaml"]), &webSvc))
+	assert.NotEmpty(t, webSvc.Spec.Selector)
+	assert.Equal(t, depl.Spec.Selector.MatchLabels, webSvc.Spec.Selector)
+	for k, v := range webSvc.Spec.Selector {
+		assert.Equal(t, v, depl.Spec.Template.Labels[k])
+	}
+
+	var sts appsv1.StatefulSet
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/statefulset.yaml"]), &sts))
+	var dbSvc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/db.yaml"]), &dbSvc))
+	assert.Equal(t, "db", dbSvc.Spec.Selector["app"])
+	for k, v := range dbSvc.Spec.Selector {
+		assert.Equal(t, v, sts.Spec.Template.Labels[k])
+	}
+}
+
+func TestTolerationFieldsOverride(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: web
+        effect: NoSchedule
+      - key: node.kubernetes.io/unreachable
+        operator: Exists
+        effect: NoExecute
+        tolerationSeconds: 300
+      containers:
+      - name: app
+        image: my-app:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, TolerationFields: true})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"myAppDeployment": map[string]interface{}{
+			"tolerations": map[string]interface{}{
+				"toleration": map[string]interface{}{"value": "api"},
+			},
+		},
+	})
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	seconds := int64(300)
+	assert.Equal(t, []corev1.Toleration{
+		{Key: "dedicated", Operator: corev1.TolerationOpEqual, Value: "api", Effect: corev1.TaintEffectNoSchedule},
+		{Key: "node.kubernetes.io/unreachable", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoExecute, TolerationSeconds: &seconds},
+	}, depl.Spec.Template.Spec.Tolerations)
+}
+
+func TestEnvValuesOverlays(t *testing.T) {
+	const deploy = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+  namespace: %[1]s
+  labels:
+    app: my-app
+    env: %[1]s
+spec:
+  replicas: %[2]d
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      nodeSelector:
+        disktype: ssd
+%[4]s
+      containers:
+      - name: app
+        image: my-app:%[3]s
+        resources:
+          limits:
+            cpu: 500m
+%[5]s`
+	input := fmt.Sprintf(deploy, "prod", 3, "v1", "        zone: eu-1a", "            memory: 1Gi") + "\n---\n" +
+		fmt.Sprintf(deploy, "staging", 1, "v2-rc", "", "")
+	const svc = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+  namespace: %[1]s
+  labels:
+    env: %[1]s
+spec:
+  selector:
+    app: my-app
+  ports:
+  - port: 80`
+	input += "\n---\n" + fmt.Sprintf(svc, "prod") + "\n---\n" + fmt.Sprintf(svc, "staging")
+	chartDir := t.TempDir()
+	// variants in other namespaces are not loaded to app metadata and don't warn about different namespaces
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, EnvLabel: "env", FailOnWarning: true})
+	assert.NoError(t, err)
+	chartPath := filepath.Join(chartDir, appChartName)
+
+	prod, err := chartutil.ReadValuesFile(filepath.Join(chartPath, "values-prod.yaml"))
+	assert.NoError(t, err)
+	assert.Empty(t, prod)
+	staging, err := chartutil.ReadValuesFile(filepath.Join(chartPath, "values-staging.yaml"))
+	assert.NoError(t, err)
+	// prod only keys are removed by null values
+	assert.Equal(t, chartutil.Values{
+		"deployment": map[string]interface{}{
+			"replicas":     float64(1),
+			"nodeSelector": map[string]interface{}{"zone": nil},
+			"app": map[string]interface{}{
+				"image":     map[string]interface{}{"tag": "v2-rc"},
+				"resources": map[string]interface{}{"limits": map[string]interface{}{"memory": nil}},
+			},
+		},
+	}, staging)
+
+	var prodDepl, stagingDepl appsv1.Deployment
+	manifests := renderChart(t, chartPath, prod)
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &prodDepl))
+	assert.Equal(t, int32(3), *prodDepl.Spec.Replicas)
+	assert.Equal(t, "my-app:v1", prodDepl.Spec.Template.Spec.Containers[0].Image)
+	assert.NotContains(t, prodDepl.Labels, "env")
+
+	manifests = renderChart(t, chartPath, staging)
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &stagingDepl))
+	assert.Equal(t, int32(1), *stagingDepl.Spec.Replicas)
+	assert.Equal(t, "my-app:v2-rc", stagingDepl.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, map[string]string{"disktype": "ssd"}, stagingDepl.Spec.Template.Spec.NodeSelector)
+	assert.Equal(t, "500m", stagingDepl.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String())
+	assert.NotContains(t, stagingDepl.Spec.Template.Spec.Containers[0].Resources.Limits, corev1.ResourceMemory)
+	assert.Equal(t, "eu-1a", prodDepl.Spec.Template.Spec.NodeSelector["zone"])
+
+	// service selector is linked to the only deployment, not to one of its variants
+	var service corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web.yaml"]), &service))
+	assert.Equal(t, appChartName, service.Spec.Selector["app.kubernetes.io/name"])
+
+	diverged := strings.Replace(input, "- name: app", "- name: sidecar\n        image: proxy:v1\n      - name: app", 1)
+	err = Start(strings.NewReader(diverged), config.Config{ChartName: appChartName, ChartDir: t.TempDir(), EnvLabel: "env"})
+	assert.ErrorContains(t, err, "staging environment variant differs from prod not only in values")
+}
+
+func TestReuseValuesStructure(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        resources:
+          limits:
+            cpu: 500m`
+	const reference = `frontend:
+  replicas: 1
+  image:
+    repository: nginx
+    tag: latest
+  resources: {}
+config:
+  logLevel: info
+`
+	chartDir := t.TempDir()
+	referenceFile := filepath.Join(t.TempDir(), "values.yaml")
+	assert.NoError(t, os.WriteFile(referenceFile, []byte(reference), 0600))
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, ValuesLayout: referenceFile})
+	assert.NoError(t, err)
+	chartPath := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chartPath, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicas":  float64(2),
+		"image":     map[string]interface{}{"repository": "nginx", "tag": "1.25"},
+		"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}},
+	}, values["frontend"])
+	assert.Equal(t, map[string]interface{}{"logLevel": "debug"}, values["config"])
+	// unmatched values keep default location
+	assert.Contains(t, values["web"], "extraEnvFrom")
+	assert.NotContains(t, values["web"], "replicas")
+
+	manifests := renderChart(t, chartPath, map[string]interface{}{
+		"frontend": map[string]interface{}{
+			"replicas": 5,
+			"image":    map[string]interface{}{"tag": "1.26"},
+		},
+	})
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	assert.Equal(t, int32(5), *depl.Spec.Replicas)
+	assert.Equal(t, "nginx:1.26", depl.Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "500m", depl.Spec.Template.Spec.Containers[0].Resources.Limits.Cpu().String())
+	var cm corev1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/config.yaml"]), &cm))
+	assert.Equal(t, "debug", cm.Data["LOG_LEVEL"])
+}
+
+func TestGRPCIngress(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-greeter
+spec:
+  selector:
+    app: greeter
+  ports:
+  - name: grpc
+    port: 50051
+    targetPort: 50051
+    appProtocol: grpc
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-api
+  annotations:
+    nginx.ingress.kubernetes.io/backend-protocol: GRPC
+    nginx.ingress.kubernetes.io/server-snippet: |
+      grpc_read_timeout 3600s;
+spec:
+  ingressClassName: nginx
+  rules:
+  - host: grpc.example.com
+    http:
+      paths:
+      - path: /helloworld.Greeter
+        pathType: ImplementationSpecific
+        backend:
+          service:
+            name: my-app-greeter
+            port:
+              name: grpc`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	var ing networkingv1.Ingress
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/api.yaml"]), &ing))
+	assert.Equal(t, "GRPC", ing.Annotations["nginx.ingress.kubernetes.io/backend-protocol"])
+	assert.Equal(t, "grpc_read_timeout 3600s;\n", ing.Annotations["nginx.ingress.kubernetes.io/server-snippet"])
+	if assert.Len(t, ing.Spec.Rules, 1) && assert.Len(t, ing.Spec.Rules[0].HTTP.Paths, 1) {
+		path := ing.Spec.Rules[0].HTTP.Paths[0]
+		assert.Equal(t, "/helloworld.Greeter", path.Path)
+		assert.Equal(t, networkingv1.PathTypeImplementationSpecific, *path.PathType)
+		assert.Equal(t, "test-test-app-greeter", path.Backend.Service.Name)
+		assert.Equal(t, networkingv1.ServiceBackendPort{Name: "grpc"}, path.Backend.Service.Port)
+	}
+
+	var svc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/greeter.yaml"]), &svc))
+	if assert.Len(t, svc.Spec.Ports, 1) && assert.NotNil(t, svc.Spec.Ports[0].AppProtocol) {
+		assert.Equal(t, "grpc", *svc.Spec.Ports[0].AppProtocol)
+	}
+}
+
+func TestStartupProbeBudget(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+        startupProbe:
+          tcpSocket:
+            port: 8080
+          failureThreshold: 30
+          periodSeconds: 5`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	probe := depl.Spec.Template.Spec.Containers[0].StartupProbe
+	assert.Equal(t, int32(30), probe.FailureThreshold)
+	assert.Equal(t, int32(5), probe.PeriodSeconds)
+
+	manifests = renderChart(t, filepath.Join(chartDir, appChartName), map[string]interface{}{
+		"myAppDeployment": map[string]interface{}{
+			"app": map[string]interface{}{
+				"startupProbe": map[string]interface{}{"failureThreshold": 60, "periodSeconds": 10},
+			},
+		},
+	})
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	probe = depl.Spec.Template.Spec.Containers[0].StartupProbe
+	assert.Equal(t, int32(60), probe.FailureThreshold)
+	assert.Equal(t, int32(10), probe.PeriodSeconds)
+	assert.Equal(t, intstr.FromInt(8080), probe.TCPSocket.Port)
+}
+
+func TestStableOrdering(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      initContainers:
+      - name: migrate
+        image: my-app:v1
+      - name: init-cache
+        image: busybox:1.36
+      containers:
+      - name: web
+        image: my-app:v1
+        env:
+        - name: ZONE
+          value: eu
+        - name: API_URL
+          value: http://api
+        - name: MODE
+          value: prod
+        ports:
+        - name: http
+          containerPort: 8080
+        - name: admin
+          containerPort: 9090
+        volumeMounts:
+        - name: data
+          mountPath: /data
+        - name: cache
+          mountPath: /cache
+      - name: agent
+        image: agent:v2
+      volumes:
+      - name: data
+        emptyDir: {}
+      - name: cache
+        emptyDir: {}`
+	readChart := func() map[string]string {
+		chartDir := t.TempDir()
+		err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+		assert.NoError(t, err)
+		files := map[string]string{}
+		err = filepath.Walk(chartDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			files[strings.TrimPrefix(path, chartDir)] = string(data)
+			return err
+		})
+		assert.NoError(t, err)
+		return files
+	}
+	first := readChart()
+	assert.Equal(t, first, readChart())
+
+	chartDir := t.TempDir()
+	assert.NoError(t, Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir}))
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	podSpec := depl.Spec.Template.Spec
+	var names []string
+	for _, c := range podSpec.InitContainers {
+		names = append(names, c.Name)
+	}
+	for _, c := range podSpec.Containers {
+		names = append(names, c.Name)
+	}
+	for _, e := range podSpec.Containers[0].Env {
+		names = append(names, e.Name)
+	}
+	for _, p := range podSpec.Containers[0].Ports {
+		names = append(names, p.Name)
+	}
+	for _, v := range podSpec.Volumes {
+		names = append(names, v.Name)
+	}
+	assert.Equal(t, []string{
+		"migrate", "init-cache",
+		"web", "agent",
+		"ZONE", "API_URL", "MODE", "KUBERNETES_CLUSTER_DOMAIN",
+		"http", "admin",
+		"data", "cache",
+	}, names)
+}
+
+func TestValuesIndent(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, ValuesIndent: 4})
+	assert.NoError(t, err)
+	chartPath := filepath.Join(chartDir, appChartName)
+
+	values, err := os.ReadFile(filepath.Join(chartPath, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(values), `myAppDeployment:
+    app:
+        image:
+            repository: my-app
+            tag: v1
+`)
+	manifests := renderChart(t, chartPath, nil)
+	var depl appsv1.Deployment
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &depl))
+	assert.Equal(t, int32(2), *depl.Spec.Replicas)
+}
+
+func TestReferenceReport(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+        envFrom:
+        - configMapRef:
+            name: my-app-config`
+	report := filepath.Join(t.TempDir(), "refs.json")
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: t.TempDir(), ReferenceReport: report})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(report)
+	assert.NoError(t, err)
+	var refs []metadata.WorkloadReferences
+	assert.NoError(t, json.Unmarshal(data, &refs))
+	assert.Equal(t, []metadata.WorkloadReferences{{
+		Kind: "Deployment",
+		Name: "my-app-deployment",
+		References: []metadata.Reference{
+			{Kind: "ConfigMap", Name: "my-app-config", Source: metadata.SourceEnvFrom, InChart: true},
+		},
+	}}, refs)
+}
+
+func TestIngressExtraAnnotations(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: web
+  ports:
+  - name: http
+    port: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-public
+  annotations:
+    nginx.ingress.kubernetes.io/proxy-body-size: 8m
+    nginx.ingress.kubernetes.io/configuration-snippet: |
+      more_set_headers "X-Frame-Options: DENY";
+      more_set_headers "X-Content-Type-Options: nosniff";
+spec:
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-web
+            port:
+              name: http`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, IngressExtraAnnotations: true})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	ingValues, err := values.Table("public.ingress")
+	assert.NoError(t, err)
+	assert.Contains(t, ingValues, "extraAnnotations")
+	assert.Empty(t, ingValues["extraAnnotations"])
+
+	render := func(vals map[string]interface{}) map[string]string {
+		manifests := renderChart(t, chart, vals)
+		var ing networkingv1.Ingress
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/public.yaml"]), &ing))
+		return ing.Annotations
+	}
+	snippet := "more_set_headers \"X-Frame-Options: DENY\";\nmore_set_headers \"X-Content-Type-Options: nosniff\";\n"
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size":       "8m",
+		"nginx.ingress.kubernetes.io/configuration-snippet": snippet,
+	}, render(nil))
+
+	assert.Equal(t, map[string]string{
+		"nginx.ingress.kubernetes.io/proxy-body-size":       "64m",
+		"nginx.ingress.kubernetes.io/configuration-snippet": snippet,
+		"cert-manager.io/cluster-issuer":                    "letsencrypt",
+	}, render(map[string]interface{}{
+		"public": map[string]interface{}{"ingress": map[string]interface{}{"extraAnnotations": map[string]interface{}{
+			"cert-manager.io/cluster-issuer":              "letsencrypt",
+			"nginx.ingress.kubernetes.io/proxy-body-size": "64m",
+		}}},
+	}))
+}
+
+func TestCSIVolumeAttributes(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-store-creds
+type: Opaque
+data:
+  clientid: Y2xpZW50
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        volumeMounts:
+        - name: secrets-store
+          mountPath: /mnt/secrets
+          readOnly: true
+      volumes:
+      - name: secrets-store
+        csi:
+          driver: secrets-store.csi.k8s.io
+          readOnly: true
+          volumeAttributes:
+            secretProviderClass: web-dev
+          nodePublishSecretRef:
+            name: my-app-store-creds`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	csiVolume := func(vals map[string]interface{}) *corev1.CSIVolumeSource {
+		vals["storeCreds"] = map[string]interface{}{"clientid": "client"}
+		manifests := renderChart(t, chart, vals)
+		var deploy appsv1.Deployment
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &deploy))
+		if !assert.Len(t, deploy.Spec.Template.Spec.Volumes, 1) {
+			return &corev1.CSIVolumeSource{}
+		}
+		return deploy.Spec.Template.Spec.Volumes[0].CSI
+	}
+	csi := csiVolume(map[string]interface{}{})
+	if assert.NotNil(t, csi) {
+		assert.Equal(t, "secrets-store.csi.k8s.io", csi.Driver)
+		assert.Equal(t, map[string]string{"secretProviderClass": "web-dev"}, csi.VolumeAttributes)
+		if assert.NotNil(t, csi.NodePublishSecretRef) {
+			assert.Equal(t, "test-test-app-store-creds", csi.NodePublishSecretRef.Name)
+		}
+	}
+
+	csi = csiVolume(map[string]interface{}{
+		"web": map[string]interface{}{"volumes": map[string]interface{}{"secretsStore": map[string]interface{}{
+			"volumeAttributes": map[string]interface{}{"secretProviderClass": "web-prod"},
+		}}},
+	})
+	if assert.NotNil(t, csi) {
+		assert.Equal(t, map[string]string{"secretProviderClass": "web-prod"}, csi.VolumeAttributes)
+	}
+}
+
+func TestCustomResourceSchemaValues(t *testing.T) {
+	const input = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: caches.example.com
+spec:
+  group: example.com
+  names:
+    kind: Cache
+    plural: caches
+    singular: cache
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: ["mode"]
+            properties:
+              mode:
+                type: string
+                enum: ["standalone", "cluster"]
+              shards:
+                type: integer
+                minimum: 1
+              memory:
+                type: string
+---
+apiVersion: example.com/v1
+kind: Cache
+metadata:
+  name: my-app-cache
+spec:
+  mode: standalone
+  shards: 3
+  memory: 1Gi`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, CustomResourceValues: true})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	cacheValues, err := values.Table("myAppCache.cache")
+	assert.NoError(t, err)
+	assert.Equal(t, chartutil.Values{"mode": "standalone", "shards": float64(3), "memory": "1Gi"}, cacheValues)
+
+	schemaFile, err := os.ReadFile(filepath.Join(chart, "values.schema.json"))
+	assert.NoError(t, err)
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(schemaFile, &schema))
+	cacheSchema := schema["properties"].(map[string]interface{})["myAppCache"].(map[string]interface{})["properties"].(map[string]interface{})["cache"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"mode"}, cacheSchema["required"])
+	assert.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"standalone", "cluster"}}, cacheSchema["properties"].(map[string]interface{})["mode"])
+
+	manifests := renderChart(t, chart, map[string]interface{}{
+		"myAppCache": map[string]interface{}{"cache": map[string]interface{}{"mode": "cluster", "shards": 6}},
+	})
+	var cache map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/my-app-cache.yaml"]), &cache))
+	assert.Equal(t, map[string]interface{}{"mode": "cluster", "shards": float64(6), "memory": "1Gi"}, cache["spec"])
+
+	chrt, err := loader.Load(chart)
+	assert.NoError(t, err)
+	_, err = chartutil.ToRenderValues(chrt, map[string]interface{}{
+		"myAppCache": map[string]interface{}{"cache": map[string]interface{}{"mode": "replicated"}},
+	}, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, chartutil.DefaultCapabilities)
+	assert.ErrorContains(t, err, "mode")
+}
+
+func TestCustomResourceSchemaValuesLayout(t *testing.T) {
+	const input = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: caches.example.com
+spec:
+  group: example.com
+  names:
+    kind: Cache
+    plural: caches
+    singular: cache
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: ["mode"]
+            properties:
+              mode:
+                type: string
+                enum: ["standalone", "cluster"]
+              shards:
+                type: integer
+                minimum: 1
+---
+apiVersion: example.com/v1
+kind: Cache
+metadata:
+  name: my-app-cache
+spec:
+  mode: standalone
+  shards: 3`
+	const reference = `redis:
+  cache:
+    mode: cluster
+    shards: 1
+`
+	chartDir := t.TempDir()
+	referenceFile := filepath.Join(t.TempDir(), "values.yaml")
+	assert.NoError(t, os.WriteFile(referenceFile, []byte(reference), 0600))
+	err := Start(strings.NewReader(input), config.Config{
+		ChartName: appChartName, ChartDir: chartDir, CustomResourceValues: true, ValuesLayout: referenceFile,
+	})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	cacheValues, err := values.Table("redis.cache")
+	assert.NoError(t, err)
+	assert.Equal(t, chartutil.Values{"mode": "standalone", "shards": float64(3)}, cacheValues)
+
+	schemaFile, err := os.ReadFile(filepath.Join(chart, "values.schema.json"))
+	assert.NoError(t, err)
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(schemaFile, &schema))
+	assert.NotContains(t, schema["properties"], "myAppCache")
+	cacheSchema := schema["properties"].(map[string]interface{})["redis"].(map[string]interface{})["properties"].(map[string]interface{})["cache"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"mode"}, cacheSchema["required"])
+
+	chrt, err := loader.Load(chart)
+	assert.NoError(t, err)
+	_, err = chartutil.ToRenderValues(chrt, map[string]interface{}{
+		"redis": map[string]interface{}{"cache": map[string]interface{}{"mode": "replicated"}},
+	}, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, chartutil.DefaultCapabilities)
+	assert.ErrorContains(t, err, "mode")
+}
+
+func TestServiceAnnotationValues(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+  annotations:
+    service.beta.kubernetes.io/aws-load-balancer-ssl-cert: arn:aws:acm:eu-west-1:123456789012:certificate/dev
+    service.beta.kubernetes.io/aws-load-balancer-ssl-ports: "443,8443"
+    service.beta.kubernetes.io/aws-load-balancer-type: nlb
+    example.com/policy: |
+      allow: internal
+      deny: all
+spec:
+  type: LoadBalancer
+  selector:
+    app: web
+  ports:
+  - name: https
+    port: 443`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{
+		ChartName:               appChartName,
+		ChartDir:                chartDir,
+		ServiceAnnotationValues: []string{"aws-load-balancer-ssl-", "^example\\.com/"},
+	})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	annotations, err := values.Table("myAppWeb.annotations")
+	assert.NoError(t, err)
+	assert.Equal(t, chartutil.Values{
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-cert":  "arn:aws:acm:eu-west-1:123456789012:certificate/dev",
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-ports": "443,8443",
+		"example.com/policy": "allow: internal\ndeny: all\n",
+	}, annotations)
+
+	render := func(vals map[string]interface{}) map[string]string {
+		manifests := renderChart(t, chart, vals)
+		var svc corev1.Service
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/my-app-web.yaml"]), &svc))
+		return svc.Annotations
+	}
+	assert.Equal(t, map[string]string{
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-cert":  "arn:aws:acm:eu-west-1:123456789012:certificate/dev",
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-ports": "443,8443",
+		"service.beta.kubernetes.io/aws-load-balancer-type":      "nlb",
+		"example.com/policy": "allow: internal\ndeny: all\n",
+	}, render(nil))
+
+	prod := render(map[string]interface{}{"myAppWeb": map[string]interface{}{"annotations": map[string]interface{}{
+		"service.beta.kubernetes.io/aws-load-balancer-ssl-cert": "arn:aws:acm:eu-west-1:210987654321:certificate/prod",
+	}}})
+	assert.Equal(t, "arn:aws:acm:eu-west-1:210987654321:certificate/prod", prod["service.beta.kubernetes.io/aws-load-balancer-ssl-cert"])
+	assert.Equal(t, "443,8443", prod["service.beta.kubernetes.io/aws-load-balancer-ssl-ports"])
+}
+
+func TestInputOrderPreserved(t *testing.T) {
+	const workers = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-batch
+spec:
+  selector:
+    matchLabels:
+      app: workers
+  template:
+    metadata:
+      labels:
+        app: workers
+    spec:
+      containers:
+      - name: worker
+        image: worker:v1
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: batch
+        effect: NoSchedule
+      - key: spot
+        operator: Exists
+        effect: NoExecute
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-batch-config
+data:
+  QUEUE: jobs`
+	const web = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: web
+  ports:
+  - port: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-web-config
+data:
+  MODE: web`
+	inputDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(inputDir, "workers.yaml"), []byte(workers), 0600))
+	assert.NoError(t, os.WriteFile(filepath.Join(inputDir, "web.yaml"), []byte(web), 0600))
+
+	generate := func() map[string]string {
+		chartDir := t.TempDir()
+		err := Start(strings.NewReader(""), config.Config{ChartName: appChartName, ChartDir: chartDir, Files: []string{inputDir}, TolerationFields: true})
+		assert.NoError(t, err)
+		res := map[string]string{}
+		for _, f := range []string{"values.yaml", "templates/workers.yaml", "templates/web.yaml"} {
+			data, err := os.ReadFile(filepath.Join(chartDir, appChartName, f))
+			assert.NoError(t, err)
+			res[f] = string(data)
+		}
+		return res
+	}
+	first := generate()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, first, generate())
+	}
+
+	// documents of every input file are written in input order
+	docs := strings.Split(first["templates/workers.yaml"], "\n---\n")
+	if assert.Len(t, docs, 2) {
+		assert.Contains(t, docs[0], "kind: Deployment")
+		assert.Contains(t, docs[1], "kind: ConfigMap")
+	}
+	docs = strings.Split(first["templates/web.yaml"], "\n---\n")
+	if assert.Len(t, docs, 2) {
+		assert.Contains(t, docs[0], "kind: Service")
+		assert.Contains(t, docs[1], "kind: ConfigMap")
+	}
+
+	// list item suffixes follow input order
+	values, err := chartutil.ReadValues([]byte(first["values.yaml"]))
+	assert.NoError(t, err)
+	tolerations, err := values.Table("batch.tolerations")
+	assert.NoError(t, err)
+	assert.Equal(t, "dedicated", tolerations["toleration"].(map[string]interface{})["key"])
+	assert.Equal(t, "spot", tolerations["toleration1"].(map[string]interface{})["key"])
+}
+
+func TestContainerWorkingDir(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:16
+        workingDir: /var/lib/postgresql
+      - name: exporter
+        image: exporter:v1`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	containers := func(vals map[string]interface{}) []corev1.Container {
+		manifests := renderChart(t, chart, vals)
+		var sts appsv1.StatefulSet
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/statefulset.yaml"]), &sts))
+		assert.Len(t, sts.Spec.Template.Spec.Containers, 2)
+		return sts.Spec.Template.Spec.Containers
+	}
+	res := containers(nil)
+	assert.Equal(t, "/var/lib/postgresql", res[0].WorkingDir)
+	assert.Empty(t, res[1].WorkingDir)
+
+	res = containers(map[string]interface{}{
+		"myAppDb": map[string]interface{}{"db": map[string]interface{}{"workingDir": "/data"}},
+	})
+	assert.Equal(t, "/data", res[0].WorkingDir)
+}
+
+func TestRequiredSecretValue(t *testing.T) {
+	const input = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-db
+type: Opaque
+stringData:
+  password: ""`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	values, err := chartutil.ReadValuesFile(filepath.Join(chart, "values.yaml"))
+	assert.NoError(t, err)
+	password, err := values.PathValue("myAppDb.password")
+	assert.NoError(t, err)
+	assert.Equal(t, "", password)
+
+	chrt, err := loader.Load(chart)
+	assert.NoError(t, err)
+	renderVals, err := chartutil.ToRenderValues(chrt, nil, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, chartutil.DefaultCapabilities)
+	assert.NoError(t, err)
+	_, err = engine.Render(chrt, renderVals)
+	assert.ErrorContains(t, err, "myAppDb.password is required")
+
+	manifests := renderChart(t, chart, map[string]interface{}{"myAppDb": map[string]interface{}{"password": "s3cret"}})
+	var sec corev1.Secret
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/my-app-db.yaml"]), &sec))
+	assert.Equal(t, "s3cret", sec.StringData["password"])
+}
+
+func TestRequiredConfigValue(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  DB_HOST: ""
+  LOG_LEVEL: info`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, RequiredValues: []string{"myAppConfig.dbHost"}})
+	assert.NoError(t, err)
+	chart := filepath.Join(chartDir, appChartName)
+
+	chrt, err := loader.Load(chart)
+	assert.NoError(t, err)
+	renderVals, err := chartutil.ToRenderValues(chrt, nil, chartutil.ReleaseOptions{Name: "test", Namespace: "test-ns"}, chartutil.DefaultCapabilities)
+	assert.NoError(t, err)
+	_, err = engine.Render(chrt, renderVals)
+	assert.ErrorContains(t, err, "myAppConfig.dbHost is required")
+
+	manifests := renderChart(t, chart, map[string]interface{}{"myAppConfig": map[string]interface{}{"dbHost": "db.local"}})
+	var cm corev1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/my-app-config.yaml"]), &cm))
+	assert.Equal(t, "db.local", cm.Data["DB_HOST"])
+	assert.Equal(t, "info", cm.Data["LOG_LEVEL"])
+}
+
+func TestBlueGreenServiceSelector(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+        color: blue
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-frontend
+spec:
+  selector:
+    app: web
+    color: blue
+  ports:
+  - name: http
+    port: 80
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-cache
+spec:
+  selector:
+    app: cache
+  ports:
+  - name: redis
+    port: 6379`
+	chartDir := t.TempDir()
+	chart := filepath.Join(chartDir, appChartName)
+	render := func(conf config.Config, vals map[string]interface{}) (map[string]string, appsv1.Deployment) {
+		conf.ChartName, conf.ChartDir = appChartName, chartDir
+		assert.NoError(t, Start(strings.NewReader(input), conf))
+		manifests := renderChart(t, chart, vals)
+		var svc corev1.Service
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/frontend.yaml"]), &svc))
+		var deploy appsv1.Deployment
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/deployment.yaml"]), &deploy))
+		return svc.Spec.Selector, deploy
+	}
+
+	selector, _ := render(config.Config{}, nil)
+	assert.NotContains(t, selector, "color")
+
+	selector, deploy := render(config.Config{BlueGreenLabel: "color"}, nil)
+	assert.Equal(t, "blue", selector["color"])
+	var cache corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(renderChart(t, chart, nil)[appChartName+"/templates/cache.yaml"]), &cache))
+	assert.NotContains(t, cache.Spec.Selector, "color", "selector without color label is not colored")
+	assert.Equal(t, "cache", cache.Spec.Selector["app"])
+	assert.Equal(t, "web", selector["app"])
+	assert.Equal(t, "blue", deploy.Spec.Template.Labels["color"])
+
+	selector, deploy = render(config.Config{BlueGreenLabel: "color"}, map[string]interface{}{
+		"activeColor": "green",
+		"web":         map[string]interface{}{"podLabels": map[string]interface{}{"color": "green"}},
+	})
+	assert.Equal(t, "green", selector["color"])
+	assert.Equal(t, "green", deploy.Spec.Template.Labels["color"])
+}
+
+func TestPriorityClassScopedQuota(t *testing.T) {
+	const input = `apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: my-app-high
+value: 1000000
+---
+apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: my-app-quota
+spec:
+  hard:
+    pods: "10"
+    requests.cpu: "4"
+  scopes:
+  - NotBestEffort
+  scopeSelector:
+    matchExpressions:
+    - operator: In
+      scopeName: PriorityClass
+      values:
+      - my-app-high`
+	chartDir := t.TempDir()
+	assert.NoError(t, Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir}))
+	chart := filepath.Join(chartDir, appChartName)
+
+	render := func(vals map[string]interface{}) corev1.ResourceQuota {
+		manifests := renderChart(t, chart, vals)
+		var quota corev1.ResourceQuota
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/quota.yaml"]), &quota))
+		return quota
+	}
+
+	quota := render(nil)
+	assert.Equal(t, []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeNotBestEffort}, quota.Spec.Scopes)
+	if assert.NotNil(t, quota.Spec.ScopeSelector) && assert.Len(t, quota.Spec.ScopeSelector.MatchExpressions, 1) {
+		expr := quota.Spec.ScopeSelector.MatchExpressions[0]
+		assert.Equal(t, corev1.ResourceQuotaScopePriorityClass, expr.ScopeName)
+		assert.Equal(t, []string{"test-test-app-high"}, expr.Values)
+	}
+	assert.Equal(t, "10", quota.Spec.Hard.Pods().String())
+
+	quota = render(map[string]interface{}{
+		"quota": map[string]interface{}{"hard": map[string]interface{}{"pods": "20"}},
+	})
+	assert.Equal(t, "20", quota.Spec.Hard.Pods().String())
+	requestsCPU := quota.Spec.Hard[corev1.ResourceRequestsCPU]
+	assert.Equal(t, "4", requestsCPU.String())
+}
+
+func TestMergeIntoExistingChart(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug`
+	const service = `
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-frontend
+spec:
+  selector:
+    app: web
+  ports:
+  - name: http
+    port: 80`
+	chartDir := t.TempDir()
+	chart := filepath.Join(chartDir, appChartName)
+	conf := config.Config{ChartName: appChartName, ChartDir: chartDir, MergeInto: true}
+	assert.ErrorContains(t, Start(strings.NewReader(input), conf), "Chart.yaml not found")
+
+	conf.MergeInto = false
+	assert.NoError(t, Start(strings.NewReader(input), conf))
+	// hand-made changes of the chart maintainer
+	const custom = "# hand-written\n"
+	assert.NoError(t, os.WriteFile(filepath.Join(chart, "templates", "custom.yaml"), []byte(custom), 0600))
+	valuesFile := filepath.Join(chart, "values.yaml")
+	values, err := os.ReadFile(valuesFile)
+	assert.NoError(t, err)
+	values = bytes.Replace(values, []byte("logLevel: debug"), []byte("logLevel: info"), 1)
+	assert.NoError(t, os.WriteFile(valuesFile, append(values, []byte("maintainer: team-a\n")...), 0600))
+	deployFile := filepath.Join(chart, "templates", "deployment.yaml")
+	deployBefore, err := os.Stat(deployFile)
+	assert.NoError(t, err)
+
+	conf.MergeInto = true
+	assert.NoError(t, Start(strings.NewReader(input+service), conf))
+
+	assert.FileExists(t, filepath.Join(chart, "templates", "frontend.yaml"))
+	content, err := os.ReadFile(filepath.Join(chart, "templates", "custom.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, custom, string(content))
+	deployAfter, err := os.Stat(deployFile)
+	assert.NoError(t, err)
+	assert.Equal(t, deployBefore.ModTime(), deployAfter.ModTime(), "unchanged template must not be rewritten")
+
+	loaded, err := loader.Load(chart)
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a", loaded.Values["maintainer"])
+	svcValues, err := chartutil.Values(loaded.Values).Table("frontend")
+	assert.NoError(t, err)
+	assert.Contains(t, svcValues, "ports")
+
+	manifests := renderChart(t, chart, nil)
+	var svc corev1.Service
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/frontend.yaml"]), &svc))
+	assert.Equal(t, int32(80), svc.Spec.Ports[0].Port)
+	// value edited by maintainer is not overridden by generated default
+	var cm corev1.ConfigMap
+	assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/config.yaml"]), &cm))
+	assert.Equal(t, "info", cm.Data["LOG_LEVEL"])
+}
+
+func TestIngressTLSCertificateSecret(t *testing.T) {
+	const input = `apiVersion: cert-manager.io/v1
+kind: Certificate
+metadata:
+  name: my-app-web-cert
+spec:
+  dnsNames:
+  - web.example.com
+  issuerRef:
+    kind: ClusterIssuer
+    name: letsencrypt
+  secretName: web-tls
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-frontend
+spec:
+  tls:
+  - hosts:
+    - web.example.com
+    secretName: web-tls
+  - hosts:
+    - legacy.example.com
+    secretName: legacy-tls
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80`
+	chartDir := t.TempDir()
+	assert.NoError(t, Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir}))
+	chart := filepath.Join(chartDir, appChartName)
+
+	render := func(vals map[string]interface{}) ([]networkingv1.IngressTLS, string) {
+		manifests := renderChart(t, chart, vals)
+		var ing networkingv1.Ingress
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/frontend.yaml"]), &ing))
+		var cert struct {
+			Spec struct {
+				SecretName string `json:"secretName"`
+			} `json:"spec"`
+		}
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web-cert.yaml"]), &cert))
+		return ing.Spec.TLS, cert.Spec.SecretName
+	}
+
+	tls, certSecret := render(nil)
+	assert.Equal(t, "test-test-app-web-tls", certSecret)
+	if assert.Len(t, tls, 2) {
+		assert.Equal(t, certSecret, tls[0].SecretName)
+		assert.Equal(t, "legacy-tls", tls[1].SecretName)
+	}
+
+	tls, _ = render(map[string]interface{}{
+		"frontend": map[string]interface{}{"ingress": map[string]interface{}{"tlsSecrets": map[string]interface{}{"legacyTls": "wildcard-tls"}}},
+	})
+	assert.Equal(t, "wildcard-tls", tls[1].SecretName)
+}
+
+func TestArtifactHubAnnotations(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      initContainers:
+      - name: migrate
+        image: registry.example.com/team/migrate:v2
+      containers:
+      - name: web
+        image: nginx:1.25
+      - name: proxy
+        image: quay.io/oauth2-proxy/oauth2-proxy:v7.5.1
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: my-app-cleanup
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: OnFailure
+          containers:
+          - name: cleanup
+            image: nginx:1.25`
+	chartDir := t.TempDir()
+	chart := filepath.Join(chartDir, appChartName)
+	conf := config.Config{
+		ChartName:          appChartName,
+		ChartDir:           chartDir,
+		ArtifactHubImages:  true,
+		ArtifactHubChanges: []string{"added=Cleanup job"},
+	}
+	// second run replaces annotations block of existing Chart.yaml
+	assert.NoError(t, Start(strings.NewReader(input), conf))
+	assert.NoError(t, Start(strings.NewReader(input), conf))
+
+	chartFile, err := os.ReadFile(filepath.Join(chart, "Chart.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(chartFile), "annotations:"))
+
+	loaded, err := loader.Load(chart)
+	assert.NoError(t, err)
+	var images []metadata.ContainerImage
+	assert.NoError(t, yaml.Unmarshal([]byte(loaded.Metadata.Annotations["artifacthub.io/images"]), &images))
+	assert.Equal(t, []metadata.ContainerImage{
+		{Name: "migrate", Image: "registry.example.com/team/migrate:v2"},
+		{Name: "web", Image: "nginx:1.25"},
+		{Name: "proxy", Image: "quay.io/oauth2-proxy/oauth2-proxy:v7.5.1"},
+	}, images)
+	assert.Equal(t, "- description: Cleanup job\n  kind: added\n", loaded.Metadata.Annotations["artifacthub.io/changes"])
+	assert.Equal(t, "0.1.0", loaded.Metadata.Version)
+}
+
+func TestEnvFromPrefix(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-settings
+data:
+  LOG_LEVEL: debug
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-settings
+stringData:
+  PASSWORD: secret
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: db
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:16
+        envFrom:
+        - prefix: APP_
+          configMapRef:
+            name: my-app-settings
+        - prefix: SECRET_
+          secretRef:
+            name: my-app-settings
+        - prefix: VAULT_
+          secretRef:
+            name: vault-credentials`
+	chartDir := t.TempDir()
+	assert.NoError(t, Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir}))
+	chart := filepath.Join(chartDir, appChartName)
+
+	render := func(vals map[string]interface{}) []corev1.EnvFromSource {
+		if vals == nil {
+			vals = map[string]interface{}{}
+		}
+		vals["settings"] = map[string]interface{}{"password": "s3cret"}
+		manifests := renderChart(t, chart, vals)
+		var sts appsv1.StatefulSet
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/statefulset.yaml"]), &sts))
+		return sts.Spec.Template.Spec.Containers[0].EnvFrom
+	}
+
+	envFrom := render(nil)
+	if assert.Len(t, envFrom, 3) {
+		assert.Equal(t, "APP_", envFrom[0].Prefix)
+		assert.Equal(t, "test-test-app-settings", envFrom[0].ConfigMapRef.Name)
+		assert.Equal(t, "SECRET_", envFrom[1].Prefix)
+		assert.Equal(t, "test-test-app-settings", envFrom[1].SecretRef.Name)
+		assert.Equal(t, "VAULT_", envFrom[2].Prefix)
+		assert.Equal(t, "vault-credentials", envFrom[2].SecretRef.Name)
+	}
+
+	// ConfigMap and Secret with the same name have separate prefixes
+	envFrom = render(map[string]interface{}{
+		"db": map[string]interface{}{"db": map[string]interface{}{"envFrom": map[string]interface{}{
+			"configMap": map[string]interface{}{"settings": map[string]interface{}{"prefix": "SETTINGS_"}},
+		}}},
+	})
+	assert.Equal(t, "SETTINGS_", envFrom[0].Prefix)
+	assert.Equal(t, "SECRET_", envFrom[1].Prefix)
+	assert.Equal(t, "VAULT_", envFrom[2].Prefix)
+}
+
+func TestServiceCorrelationLabels(t *testing.T) {
+	const input = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-api
+spec:
+  selector:
+    matchLabels:
+      component: api
+      tier: server
+  template:
+    metadata:
+      labels:
+        component: api
+        tier: server
+        part-of: shop
+    spec:
+      containers:
+      - name: api
+        image: nginx:1.25
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-worker
+spec:
+  selector:
+    matchLabels:
+      component: api
+      role: worker
+  template:
+    metadata:
+      labels:
+        component: api
+        role: worker
+        part-of: shop
+    spec:
+      containers:
+      - name: worker
+        image: nginx:1.25
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-frontend
+spec:
+  selector:
+    component: api
+    part-of: shop
+  ports:
+  - name: http
+    port: 80`
+	chartDir := t.TempDir()
+	chart := filepath.Join(chartDir, appChartName)
+	selector := func(conf config.Config) map[string]string {
+		conf.ChartName, conf.ChartDir = appChartName, chartDir
+		assert.NoError(t, Start(strings.NewReader(input), conf))
+		manifests := renderChart(t, chart, nil)
+		var svc corev1.Service
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/frontend.yaml"]), &svc))
+		return svc.Spec.Selector
+	}
+
+	// selector matches pods of both deployments and is kept as it is
+	assert.Equal(t, "shop", selector(config.Config{})["part-of"])
+
+	// role identifies the worker, so the service is linked to the api deployment
+	linked := selector(config.Config{CorrelationLabels: []string{"component", "role"}})
+	assert.NotContains(t, linked, "part-of")
+	assert.Equal(t, "api", linked["component"])
+	assert.Equal(t, "server", linked["tier"])
+	assert.Equal(t, appChartName, linked["app.kubernetes.io/name"])
+}
+
+func TestFailOnWarning(t *testing.T) {
+	const input = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-web
+spec:
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-missing
+            port:
+              number: 80`
+	const customResource = `apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: my-app-widget
+spec:
+  size: 1`
+	chartDir := t.TempDir()
+	// warnings are collected even if not logged at the log level
+	conf := config.Config{ChartName: appChartName, ChartDir: chartDir, FailOnWarning: true, LogLevel: "error"}
+	err := Start(strings.NewReader(input), conf)
+	assert.ErrorContains(t, err, "fail on warning: Ingress backend service not found in chart")
+	assert.ErrorContains(t, err, "Service=my-app-missing")
+	assert.NoFileExists(t, filepath.Join(chartDir, appChartName, "Chart.yaml"))
+	assert.Equal(t, logrus.ErrorLevel, logrus.GetLevel())
+
+	// resource processed by default processor is not a warning
+	assert.NoError(t, Start(strings.NewReader(customResource), conf))
+	assert.FileExists(t, filepath.Join(chartDir, appChartName, "Chart.yaml"))
+
+	conf.FailOnWarning = false
+	assert.NoError(t, Start(strings.NewReader(input), conf))
+}
+
+func TestExtraTemplatesKept(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  LOG_LEVEL: debug`
+	const custom = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "test-app.fullname" . }}-custom
+data:
+  key: value
+`
+	chartDir := t.TempDir()
+	conf := config.Config{ChartName: appChartName, ChartDir: chartDir, ExtraTemplates: true}
+	err := Start(strings.NewReader(input), conf)
+	assert.NoError(t, err)
+	extraDir := filepath.Join(chartDir, appChartName, "templates", "extra")
+	assert.FileExists(t, filepath.Join(extraDir, ".gitkeep"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(extraDir, "custom.yaml"), []byte(custom), 0600))
+	err = Start(strings.NewReader(input), conf)
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(extraDir, "custom.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, custom, string(content))
+	manifests := renderChart(t, filepath.Join(chartDir, appChartName), nil)
+	assert.Contains(t, manifests[appChartName+"/templates/extra/custom.yaml"], "name: test-test-app-custom")
+}
+
+func TestIngressSharedDomain(t *testing.T) {
+	const input = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-api
+spec:
+  tls:
+  - hosts:
+    - api.example.com
+    secretName: api-tls
+  rules:
+  - host: api.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: api
+            port:
+              number: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app-web
+spec:
+  rules:
+  - host: web.example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80
+  - host: web.example.org
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80`
+	chartDir := t.TempDir()
+	err := Start(strings.NewReader(input), config.Config{ChartName: appChartName, ChartDir: chartDir, IngressDomain: "example.com"})
+	assert.NoError(t, err)
+
+	values, err := os.ReadFile(filepath.Join(chartDir, appChartName, "values.yaml"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(values), "global:\n  domain: example.com")
+
+	render := func(vals map[string]interface{}) (networkingv1.Ingress, networkingv1.Ingress) {
+		manifests := renderChart(t, filepath.Join(chartDir, appChartName), vals)
+		var api, web networkingv1.Ingress
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/api.yaml"]), &api))
+		assert.NoError(t, yaml.Unmarshal([]byte(manifests[appChartName+"/templates/web.yaml"]), &web))
+		return api, web
+	}
+	api, web := render(nil)
+	assert.Equal(t, "api.example.com", api.Spec.Rules[0].Host)
+	assert.Equal(t, []string{"api.example.com"}, api.Spec.TLS[0].Hosts)
+	assert.Equal(t, "web.example.com", web.Spec.Rules[0].Host)
+	assert.Equal(t, "web.example.org", web.Spec.Rules[1].Host)
+
+	api, web = render(map[string]interface{}{
+		"global": map[string]interface{}{"domain": "staging.example.net"},
+	})
+	assert.Equal(t, "api.staging.example.net", api.Spec.Rules[0].Host)
+	assert.Equal(t, []string{"api.staging.example.net"}, api.Spec.TLS[0].Hosts)
+	assert.Equal(t, "web.staging.example.net", web.Spec.Rules[0].Host)
+	assert.Equal(t, "web.example.org", web.Spec.Rules[1].Host)
+}
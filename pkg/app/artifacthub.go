@@ -0,0 +1,46 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helm"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	artifactHubImages  = "artifacthub.io/images"
+	artifactHubChanges = "artifacthub.io/changes"
+)
+
+// artifactHubChange - entry of artifacthub.io/changes annotation.
+type artifactHubChange struct {
+	Kind        string `json:"kind"`
+	Description string `json:"description"`
+}
+
+// writeArtifactHubAnnotations sets Artifact Hub annotations of Chart.yaml if enabled in config.
+// Images annotation lists container images of chart pod specs, changes annotation lists changes from config.
+func (c *appContext) writeArtifactHubAnnotations() error {
+	annotations := map[string]string{}
+	if images := c.appMeta.Images(); c.config.ArtifactHubImages && len(images) != 0 {
+		res, err := yaml.Marshal(images)
+		if err != nil {
+			return fmt.Errorf("%w: unable to marshal %s annotation", err, artifactHubImages)
+		}
+		annotations[artifactHubImages] = string(res)
+	}
+	if len(c.config.ArtifactHubChanges) != 0 {
+		changes := make([]artifactHubChange, len(c.config.ArtifactHubChanges))
+		for i, change := range c.config.ArtifactHubChanges {
+			kind, description, _ := strings.Cut(change, "=")
+			changes[i] = artifactHubChange{Kind: kind, Description: description}
+		}
+		res, err := yaml.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("%w: unable to marshal %s annotation", err, artifactHubChanges)
+		}
+		annotations[artifactHubChanges] = string(res)
+	}
+	return helm.SetChartAnnotations(c.config, annotations)
+}
@@ -1,9 +1,16 @@
 package app
 
 import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
 	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/arttor/helmify/pkg/warning"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -17,6 +24,10 @@ type appContext struct {
 	appMeta          *metadata.Service
 	objects          []*unstructured.Unstructured
 	fileNames        []string
+	// warnings - collected warnings failing chart generation. Nil if warnings are not fatal.
+	warnings *warning.Collector
+	// envVariantKeys - keys of resources with environment variant loaded into app metadata.
+	envVariantKeys map[string]bool
 }
 
 // New returns context with config set.
@@ -40,27 +51,76 @@ func (c *appContext) WithDefaultProcessor(processor helmify.Processor) *appConte
 	return c
 }
 
+// WithWarnings  sets collector of warnings failing chart generation to the context and returns it.
+func (c *appContext) WithWarnings(warnings *warning.Collector) *appContext {
+	c.warnings = warnings
+	return c
+}
+
 // Add k8s object to app context.
 func (c *appContext) Add(obj *unstructured.Unstructured, filename string) {
 	// we need to add all objects before start processing only to define app metadata.
-	c.appMeta.Load(obj)
+	// Only the first environment variant of resource is loaded, others are processed as values overlays.
+	if c.isEnvVariantLoaded(obj) {
+		objLog(obj).WithField("File", filename).Debug("environment variant: not loaded to app metadata")
+	} else {
+		c.appMeta.Load(obj)
+	}
+	objLog(obj).WithField("File", filename).Debug("loaded")
 	c.objects = append(c.objects, obj)
 	c.fileNames = append(c.fileNames, filename)
 }
 
+// isEnvVariantLoaded returns true if obj is environment variant of resource already loaded to app metadata.
+// Marks obj resource as loaded otherwise.
+func (c *appContext) isEnvVariantLoaded(obj *unstructured.Unstructured) bool {
+	if c.config.EnvLabel == "" {
+		return false
+	}
+	if _, ok := obj.GetLabels()[c.config.EnvLabel]; !ok {
+		return false
+	}
+	key := envKey(obj)
+	if c.envVariantKeys[key] {
+		return true
+	}
+	if c.envVariantKeys == nil {
+		c.envVariantKeys = map[string]bool{}
+	}
+	c.envVariantKeys[key] = true
+	return false
+}
+
 // CreateHelm creates helm chart from context k8s objects.
 func (c *appContext) CreateHelm(stop <-chan struct{}) error {
 	logrus.WithFields(logrus.Fields{
 		"ChartName": c.appMeta.ChartName(),
 		"Namespace": c.appMeta.Namespace(),
 	}).Info("creating a chart")
+	variants, err := c.splitEnvVariants()
+	if err != nil {
+		return err
+	}
+	if err := c.dedup(); err != nil {
+		return err
+	}
+	if err := c.mergeRoles(); err != nil {
+		return err
+	}
 	var templates []helmify.Template
 	var filenames []string
+	var unsupported []string
 	for i, obj := range c.objects {
-		template, err := c.process(obj)
+		// default processor consumes object metadata, so keep object description beforehand
+		objDesc := fmt.Sprintf("%s: %s", obj.GroupVersionKind().String(), obj.GetName())
+		key := envKey(obj)
+		template, supported, err := c.process(obj)
 		if err != nil {
 			return err
 		}
+		if !supported && template != nil {
+			unsupported = append(unsupported, objDesc)
+		}
 		if template != nil {
 			templates = append(templates, template)
 			filename := template.Filename()
@@ -68,6 +128,16 @@ func (c *appContext) CreateHelm(stop <-chan struct{}) error {
 				filename = c.fileNames[i]
 			}
 			filenames = append(filenames, filename)
+			if v, ok := variants[key]; ok {
+				overlays, err := c.processEnvVariants(key, template, v)
+				if err != nil {
+					return err
+				}
+				templates = append(templates, overlays...)
+				for _, o := range overlays {
+					filenames = append(filenames, o.Filename())
+				}
+			}
 		}
 		select {
 		case <-stop:
@@ -75,31 +145,115 @@ func (c *appContext) CreateHelm(stop <-chan struct{}) error {
 		default:
 		}
 	}
-	return c.output.Create(c.config.ChartDir, c.config.ChartName, c.config.Crd, c.config.CertManagerAsSubchart, c.config.CertManagerVersion , templates, filenames)
+	if c.config.Strict && len(unsupported) != 0 {
+		return fmt.Errorf("strict mode: no processor for resources: %s", strings.Join(unsupported, "; "))
+	}
+	if c.config.ValuesLayout != "" {
+		reference, err := loadValuesLayout(c.config.ValuesLayout)
+		if err != nil {
+			return err
+		}
+		templates, err = applyValuesLayout(reference, templates)
+		if err != nil {
+			return err
+		}
+	}
+	// chart is not written if warnings are fatal
+	if err = c.warnings.Err(); err != nil {
+		return err
+	}
+	if err = c.output.Create(c.config, templates, filenames); err != nil {
+		return err
+	}
+	if err = c.writeArtifactHubAnnotations(); err != nil {
+		return err
+	}
+	return c.writeReferenceReport()
+}
+
+// writeReferenceReport writes JSON report of objects referenced by chart workloads if enabled in config.
+func (c *appContext) writeReferenceReport() error {
+	file := c.config.ReferenceReport
+	if file == "" {
+		return nil
+	}
+	report, err := json.MarshalIndent(c.appMeta.References(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal reference report", err)
+	}
+	if err = os.WriteFile(file, append(report, '\n'), 0600); err != nil {
+		return fmt.Errorf("%w: unable to write reference report %s", err, file)
+	}
+	logrus.WithField("file", file).Info("reference report written")
+	return nil
+}
+
+// dedup removes duplicated objects with the same GVK, namespace and name. Returns error if duplicates differ.
+func (c *appContext) dedup() error {
+	seen := map[string]*unstructured.Unstructured{}
+	var objects []*unstructured.Unstructured
+	var fileNames []string
+	for i, obj := range c.objects {
+		key := fmt.Sprintf("%s: %s/%s", obj.GroupVersionKind().String(), obj.GetNamespace(), obj.GetName())
+		if prev, ok := seen[key]; ok {
+			if !reflect.DeepEqual(prev.Object, obj.Object) {
+				return fmt.Errorf("duplicate resource with different content: %s", key)
+			}
+			logrus.WithField("Resource", key).Debug("skipping duplicate resource")
+			continue
+		}
+		seen[key] = obj
+		objects = append(objects, obj)
+		fileNames = append(fileNames, c.fileNames[i])
+	}
+	c.objects, c.fileNames = objects, fileNames
+	return nil
 }
 
-func (c *appContext) process(obj *unstructured.Unstructured) (helmify.Template, error) {
+// process converts object into helm template. Returns false if none of registered processors supports the object
+// and default processor was used.
+func (c *appContext) process(obj *unstructured.Unstructured) (helmify.Template, bool, error) {
+	log := objLog(obj)
 	for _, p := range c.processors {
 		if processed, result, err := p.Process(c.appMeta, obj); processed {
 			if err != nil {
-				return nil, err
+				return nil, true, err
 			}
-			logrus.WithFields(logrus.Fields{
-				"ApiVersion": obj.GetAPIVersion(),
-				"Kind":       obj.GetKind(),
-				"Name":       obj.GetName(),
-			}).Debug("processed")
-			return result, nil
+			logProcessed(log, p, result)
+			return result, true, nil
 		}
 	}
 	if c.defaultProcessor == nil {
-		logrus.WithFields(logrus.Fields{
-			"ApiVersion": obj.GetAPIVersion(),
-			"Kind":       obj.GetKind(),
-			"Name":       obj.GetName(),
-		}).Warn("Skipping: no suitable processor for resource.")
-		return nil, nil
+		warning.Report(log, "Skipping: no suitable processor for resource.")
+		return nil, false, nil
 	}
 	_, t, err := c.defaultProcessor.Process(c.appMeta, obj)
-	return t, err
+	if err != nil {
+		return nil, false, err
+	}
+	logProcessed(log, c.defaultProcessor, t)
+	return t, false, nil
+}
+
+// objLog returns log entry describing given object. Must be called before processing because
+// processors may consume object metadata.
+func objLog(obj *unstructured.Unstructured) *logrus.Entry {
+	return logrus.WithFields(logrus.Fields{
+		"ApiVersion": obj.GetAPIVersion(),
+		"Kind":       obj.GetKind(),
+		"Name":       obj.GetName(),
+	})
+}
+
+// logProcessed logs processor decision for the object and values extracted by the processor.
+func logProcessed(log *logrus.Entry, p helmify.Processor, t helmify.Template) {
+	log = log.WithField("Processor", strings.TrimPrefix(fmt.Sprintf("%T", p), "*"))
+	if t == nil {
+		log.Debug("skipped: processor produced no template")
+		return
+	}
+	log.WithFields(logrus.Fields{
+		"Template": t.Filename(),
+		"Values":   t.Values().Paths(),
+	}).Debug("processed")
 }
@@ -0,0 +1,352 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/arttor/helmify/pkg/processor/configmap"
+	"github.com/arttor/helmify/pkg/processor/deployment"
+	"github.com/arttor/helmify/pkg/processor/hpa"
+	"github.com/arttor/helmify/pkg/processor/rbac"
+	"github.com/arttor/helmify/pkg/processor/replicationcontroller"
+	"github.com/arttor/helmify/pkg/processor/secret"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	cmYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+data:
+  key: value`
+	crYaml = `apiVersion: example.com/v1
+kind: MyResource
+metadata:
+  name: my-app-resource
+spec:
+  size: 1`
+	deployYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25`
+	workerYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-worker
+spec:
+  selector:
+    matchLabels:
+      app: worker
+  template:
+    metadata:
+      labels:
+        app: worker
+    spec:
+      priorityClassName: system-cluster-critical
+      containers:
+      - name: worker
+        image: worker:1.0
+        envFrom:
+        - secretRef:
+            name: external-env`
+)
+
+type testOutput struct {
+	templates []helmify.Template
+	filenames []string
+}
+
+func (o *testOutput) Create(_ config.Config, templates []helmify.Template, filenames []string) error {
+	o.templates = templates
+	o.filenames = filenames
+	return nil
+}
+
+func Test_appContext_Strict(t *testing.T) {
+	newCtx := func(conf config.Config) (*appContext, *testOutput) {
+		out := &testOutput{}
+		ctx := New(conf, out).
+			WithProcessors(configmap.New()).
+			WithDefaultProcessor(processor.Default())
+		ctx.Add(internal.GenerateObj(cmYaml), "")
+		ctx.Add(internal.GenerateObj(crYaml), "")
+		ctx.Add(internal.TestNs, "")
+		return ctx, out
+	}
+	t.Run("unknown resource passed through by default", func(t *testing.T) {
+		ctx, out := newCtx(config.Config{ChartName: "chart"})
+		err := ctx.CreateHelm(nil)
+		assert.NoError(t, err)
+		assert.Len(t, out.templates, 2)
+	})
+	t.Run("strict mode fails on unknown resource", func(t *testing.T) {
+		ctx, out := newCtx(config.Config{ChartName: "chart", Strict: true})
+		err := ctx.CreateHelm(nil)
+		assert.ErrorContains(t, err, "example.com/v1, Kind=MyResource: my-app-resource")
+		assert.NotContains(t, err.Error(), "ConfigMap")
+		assert.NotContains(t, err.Error(), "Namespace")
+		assert.Empty(t, out.templates)
+	})
+}
+
+func Test_appContext_Duplicates(t *testing.T) {
+	newCtx := func(objYaml ...string) (*appContext, *testOutput) {
+		out := &testOutput{}
+		ctx := New(config.Config{ChartName: "chart"}, out).
+			WithProcessors(deployment.New()).
+			WithDefaultProcessor(processor.Default())
+		for i, y := range objYaml {
+			ctx.Add(internal.GenerateObj(y), fmt.Sprintf("file%d.yaml", i))
+		}
+		return ctx, out
+	}
+	t.Run("identical duplicates merged", func(t *testing.T) {
+		ctx, out := newCtx(deployYaml, deployYaml)
+		err := ctx.CreateHelm(nil)
+		assert.NoError(t, err)
+		assert.Len(t, out.templates, 1)
+		assert.Equal(t, []string{"file0.yaml"}, out.filenames)
+	})
+	t.Run("different duplicates rejected", func(t *testing.T) {
+		ctx, _ := newCtx(deployYaml, strings.ReplaceAll(deployYaml, "nginx:1.25", "nginx:1.26"))
+		err := ctx.CreateHelm(nil)
+		assert.ErrorContains(t, err, "duplicate resource with different content: apps/v1, Kind=Deployment: /my-app-web")
+	})
+}
+
+func Test_appContext_Logging(t *testing.T) {
+	hook := test.NewGlobal()
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() {
+		logrus.SetLevel(level)
+		logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	})
+
+	ctx := New(config.Config{ChartName: "chart"}, &testOutput{}).
+		WithProcessors(configmap.New(), deployment.New()).
+		WithDefaultProcessor(processor.Default())
+	ctx.Add(internal.GenerateObj(cmYaml), "cm.yaml")
+	ctx.Add(internal.GenerateObj(deployYaml), "")
+	ctx.Add(internal.GenerateObj(crYaml), "")
+	ctx.Add(internal.TestNs, "")
+	ctx.Add(internal.GenerateObj(workerYaml), "")
+	assert.NoError(t, ctx.CreateHelm(nil))
+
+	entries := map[string]*logrus.Entry{}
+	for _, e := range hook.AllEntries() {
+		if name, ok := e.Data["Name"].(string); ok && e.Message != "loaded" {
+			entries[name] = e
+		}
+	}
+	cm := entries["my-app-config"]
+	if assert.NotNil(t, cm) {
+		assert.Equal(t, "processed", cm.Message)
+		assert.Equal(t, "configmap.configMap", cm.Data["Processor"])
+		assert.Equal(t, []string{"config.key"}, cm.Data["Values"])
+	}
+	web := entries["my-app-web"]
+	if assert.NotNil(t, web) {
+		assert.Equal(t, "deployment.deployment", web.Data["Processor"])
+		assert.Contains(t, web.Data["Values"], "web.web.image.repository")
+	}
+	cr := entries["my-app-resource"]
+	if assert.NotNil(t, cr) {
+		assert.Equal(t, "processor.dft", cr.Data["Processor"])
+		assert.Equal(t, "MyResource", cr.Data["Kind"])
+	}
+	ns := entries[internal.TestNs.GetName()]
+	if assert.NotNil(t, ns) {
+		assert.Equal(t, "skipped: processor produced no template", ns.Message)
+	}
+	var loaded int
+	processorEntries := map[string]*logrus.Entry{}
+	for _, e := range hook.AllEntries() {
+		if e.Message == "loaded" {
+			loaded++
+		}
+		if _, ok := e.Data["Workload"]; ok {
+			processorEntries[e.Message] = e
+		}
+	}
+	assert.Equal(t, 5, loaded)
+	// processor decisions
+	envFrom := processorEntries["envFrom source not in chart: name kept"]
+	if assert.NotNil(t, envFrom) {
+		assert.Equal(t, logrus.Fields{"Workload": "worker", "Container": "worker", "Source": "external-env"}, envFrom.Data)
+	}
+	priorityClass := processorEntries["priority class not in chart: name kept"]
+	if assert.NotNil(t, priorityClass) {
+		assert.Equal(t, "system-cluster-critical", priorityClass.Data["PriorityClass"])
+	}
+}
+
+func Test_appContext_ProcessorLogging(t *testing.T) {
+	const input = `apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: my-app-web
+  finalizers:
+  - example.com/cleanup
+spec:
+  selector:
+    app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app-web
+spec:
+  scaleTargetRef:
+    apiVersion: v1
+    kind: ReplicationController
+    name: my-app-web
+  maxReplicas: 3
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: my-app-token
+  annotations:
+    kubernetes.io/service-account.name: my-app
+type: kubernetes.io/service-account-token`
+	hook := test.NewGlobal()
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	t.Cleanup(func() {
+		logrus.SetLevel(level)
+		logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+	})
+
+	ctx := New(config.Config{ChartName: "chart", ConvertReplicationControllers: true}, &testOutput{}).
+		WithProcessors(replicationcontroller.New(), hpa.New(), secret.New())
+	for _, y := range strings.Split(input, "---\n") {
+		ctx.Add(internal.GenerateObj(y), "")
+	}
+	assert.NoError(t, ctx.CreateHelm(nil))
+
+	entries := map[string]logrus.Fields{}
+	for _, e := range hook.AllEntries() {
+		entries[e.Message] = e.Data
+	}
+	assert.Equal(t, logrus.Fields{"ReplicationController": "my-app-web"}, entries["converted to Deployment"])
+	assert.Equal(t, logrus.Fields{"Kind": "Deployment", "Name": "my-app-web", "Finalizers": 1}, entries["finalizers not preserved: removed"])
+	assert.Equal(t, logrus.Fields{"HorizontalPodAutoscaler": "my-app-web", "Target": "Deployment/my-app-web"}, entries["scale target found in chart: templated"])
+	assert.Equal(t, logrus.Fields{"Secret": "my-app-token"}, entries["service account token data populated by cluster: removed"])
+}
+
+func Test_appContext_MergeRoles(t *testing.T) {
+	const (
+		roleYaml = `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  name: %s
+rules:
+- apiGroups: [""]
+  resources: ["%s"]
+  verbs: ["get", "list"]`
+		bindingYaml = `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: %s
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: %s
+subjects:
+- kind: ServiceAccount
+  name: my-app-sa`
+	)
+	newCtx := func(conf config.Config, viewerResources string) (*appContext, *testOutput) {
+		out := &testOutput{}
+		ctx := New(conf, out).
+			WithProcessors(rbac.Role(), rbac.RoleBinding()).
+			WithDefaultProcessor(processor.Default())
+		ctx.Add(internal.GenerateObj(fmt.Sprintf(roleYaml, "my-app-reader", "pods")), "")
+		ctx.Add(internal.GenerateObj(fmt.Sprintf(roleYaml, "my-app-viewer", viewerResources)), "")
+		ctx.Add(internal.GenerateObj(fmt.Sprintf(bindingYaml, "my-app-reader-binding", "my-app-reader")), "")
+		ctx.Add(internal.GenerateObj(fmt.Sprintf(bindingYaml, "my-app-viewer-binding", "my-app-viewer")), "")
+		return ctx, out
+	}
+	written := func(t *testing.T, templates []helmify.Template) []string {
+		var res []string
+		for _, tmpl := range templates {
+			var buf strings.Builder
+			assert.NoError(t, tmpl.Write(&buf))
+			res = append(res, buf.String())
+		}
+		return res
+	}
+	t.Run("identical roles merged", func(t *testing.T) {
+		ctx, out := newCtx(config.Config{ChartName: "chart", MergeRBACRoles: true}, "pods")
+		assert.NoError(t, ctx.CreateHelm(nil))
+		res := written(t, out.templates)
+		assert.Len(t, res, 3)
+		assert.Contains(t, res[0], `name: {{ include "chart.fullname" . }}-reader`)
+		for _, binding := range res[1:] {
+			assert.Contains(t, binding, `  name: '{{ include "chart.fullname" . }}-reader'`)
+		}
+	})
+	t.Run("different roles kept", func(t *testing.T) {
+		ctx, out := newCtx(config.Config{ChartName: "chart", MergeRBACRoles: true}, "services")
+		assert.NoError(t, ctx.CreateHelm(nil))
+		res := written(t, out.templates)
+		assert.Len(t, res, 4)
+		assert.Contains(t, res[3], `  name: '{{ include "chart.fullname" . }}-viewer'`)
+	})
+	t.Run("role and cluster role kept", func(t *testing.T) {
+		hook := test.NewGlobal()
+		level := logrus.GetLevel()
+		logrus.SetLevel(logrus.InfoLevel)
+		t.Cleanup(func() {
+			logrus.SetLevel(level)
+			logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{})
+		})
+		ctx, out := newCtx(config.Config{ChartName: "chart", MergeRBACRoles: true}, "pods")
+		ctx.Add(internal.GenerateObj(strings.Replace(fmt.Sprintf(roleYaml, "my-app-cluster-reader", "pods"), "kind: Role", "kind: ClusterRole", 1)), "")
+		assert.NoError(t, ctx.CreateHelm(nil))
+		assert.Len(t, out.templates, 4)
+		var reported bool
+		for _, e := range hook.AllEntries() {
+			if e.Message == "Role and ClusterRole have identical rules: not merged" {
+				reported = true
+				// informational, not a warning
+				assert.Equal(t, logrus.InfoLevel, e.Level)
+			}
+		}
+		assert.True(t, reported)
+	})
+	t.Run("disabled by default", func(t *testing.T) {
+		ctx, out := newCtx(config.Config{ChartName: "chart"}, "pods")
+		assert.NoError(t, ctx.CreateHelm(nil))
+		assert.Len(t, out.templates, 4)
+	})
+}
@@ -0,0 +1,157 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// envVariants - environment variants of the same resource. The first variant from the input is the base one.
+type envVariants struct {
+	baseEnv string
+	others  []envVariant
+}
+
+type envVariant struct {
+	env string
+	obj *unstructured.Unstructured
+}
+
+// envOverlay - values of environment variant which differ from the base variant values.
+type envOverlay struct {
+	env    string
+	values helmify.Values
+}
+
+var _ helmify.ValuesOverlay = &envOverlay{}
+
+func (o *envOverlay) Env() string {
+	return o.env
+}
+
+func (o *envOverlay) Filename() string {
+	return "values-" + o.env + ".yaml"
+}
+
+func (o *envOverlay) Values() helmify.Values {
+	return o.values
+}
+
+func (o *envOverlay) Write(_ io.Writer) error {
+	return nil
+}
+
+// envKey - identifies environment variants of the same resource. Variants may live in different namespaces.
+func envKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s: %s", obj.GroupVersionKind().String(), obj.GetName())
+}
+
+// splitEnvVariants removes environment label configured by EnvLabel from objects and groups objects with the same
+// kind and name by environment. Only the first variant of every resource is kept for processing. Returns other variants
+// by resource key.
+func (c *appContext) splitEnvVariants() (map[string]*envVariants, error) {
+	label := c.config.EnvLabel
+	if label == "" {
+		return nil, nil
+	}
+	variants := map[string]*envVariants{}
+	var objects []*unstructured.Unstructured
+	var fileNames []string
+	for i, obj := range c.objects {
+		labels := obj.GetLabels()
+		env, ok := labels[label]
+		if !ok {
+			objects = append(objects, obj)
+			fileNames = append(fileNames, c.fileNames[i])
+			continue
+		}
+		delete(labels, label)
+		obj.SetLabels(labels)
+		key := envKey(obj)
+		v, ok := variants[key]
+		if !ok {
+			variants[key] = &envVariants{baseEnv: env}
+			objects = append(objects, obj)
+			fileNames = append(fileNames, c.fileNames[i])
+			continue
+		}
+		if env == v.baseEnv {
+			return nil, fmt.Errorf("resource %s has more than one variant for environment %q", key, env)
+		}
+		for _, other := range v.others {
+			if other.env == env {
+				return nil, fmt.Errorf("resource %s has more than one variant for environment %q", key, env)
+			}
+		}
+		v.others = append(v.others, envVariant{env: env, obj: obj})
+	}
+	c.objects, c.fileNames = objects, fileNames
+	return variants, nil
+}
+
+// processEnvVariants processes environment variants of the resource templated by base template.
+// Returns values overlay for every environment. Base environment overlay is empty, because its values are
+// stored in values.yaml. Returns error if variant template differs from the base one, i.e. environments can't be
+// distinguished by values only.
+func (c *appContext) processEnvVariants(key string, base helmify.Template, variants *envVariants) ([]helmify.Template, error) {
+	overlays := []helmify.Template{&envOverlay{env: variants.baseEnv, values: helmify.Values{}}}
+	var baseBuf bytes.Buffer
+	if err := base.Write(&baseBuf); err != nil {
+		return nil, err
+	}
+	for _, v := range variants.others {
+		tmpl, _, err := c.process(v.obj)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl == nil {
+			return nil, fmt.Errorf("resource %s: %s environment variant produced no template", key, v.env)
+		}
+		var buf bytes.Buffer
+		if err = tmpl.Write(&buf); err != nil {
+			return nil, err
+		}
+		if buf.String() != baseBuf.String() {
+			return nil, fmt.Errorf("resource %s: %s environment variant differs from %s not only in values", key, v.env, variants.baseEnv)
+		}
+		diff := diffValues(base.Values(), tmpl.Values())
+		logrus.WithFields(logrus.Fields{
+			"Resource": key,
+			"Env":      v.env,
+			"Values":   helmify.Values(diff).Paths(),
+		}).Debug("environment overlay values")
+		overlays = append(overlays, &envOverlay{env: v.env, values: diff})
+	}
+	return overlays, nil
+}
+
+// diffValues returns variant values which are absent in base or differ from base. Lists are compared as a whole.
+// Base values absent in variant are returned as nil, so values-<env>.yaml renders them as null and Helm removes
+// them from base values instead of inheriting them.
+func diffValues(base, variant map[string]interface{}) map[string]interface{} {
+	res := map[string]interface{}{}
+	for k, v := range variant {
+		baseMap, baseIsMap := base[k].(map[string]interface{})
+		varMap, varIsMap := v.(map[string]interface{})
+		if baseIsMap && varIsMap {
+			if d := diffValues(baseMap, varMap); len(d) != 0 {
+				res[k] = d
+			}
+			continue
+		}
+		if !reflect.DeepEqual(base[k], v) {
+			res[k] = v
+		}
+	}
+	for k := range base {
+		if _, ok := variant[k]; !ok {
+			res[k] = nil
+		}
+	}
+	return res
+}
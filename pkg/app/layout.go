@@ -0,0 +1,492 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
+)
+
+// valuesRefRe matches values references in templates, e.g. '.Values.web.replicas'.
+var valuesRefRe = regexp.MustCompile(`\.Values\.([A-Za-z0-9_]+(?:\.[A-Za-z0-9_]+)*)`)
+
+// loadValuesLayout reads reference values file.
+func loadValuesLayout(file string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read reference values %s", err, file)
+	}
+	res := map[string]interface{}{}
+	if err = yaml.Unmarshal(data, &res); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse reference values %s", err, file)
+	}
+	return res, nil
+}
+
+// applyValuesLayout moves values referenced by templates to locations of reference values layout. Values are moved
+// together with the template references. Values without matching location in reference layout keep default location.
+func applyValuesLayout(reference map[string]interface{}, templates []helmify.Template) ([]helmify.Template, error) {
+	merged := helmify.Values{}
+	texts := make([]string, len(templates))
+	for i, t := range templates {
+		if err := merged.Merge(t.Values()); err != nil {
+			return nil, err
+		}
+		if _, isOverlay := t.(helmify.ValuesOverlay); isOverlay {
+			continue
+		}
+		var buf bytes.Buffer
+		if err := t.Write(&buf); err != nil {
+			return nil, err
+		}
+		texts[i] = buf.String()
+	}
+	moves := matchValuesLayout(reference, merged, valuesUnits(merged, texts))
+	if len(moves) == 0 {
+		return templates, nil
+	}
+	for _, from := range sortedKeys(moves) {
+		logrus.WithFields(logrus.Fields{"From": from, "To": moves[from]}).Debug("value moved to reference layout")
+	}
+	res := make([]helmify.Template, len(templates))
+	for i, t := range templates {
+		values := moveValues(t.Values(), moves)
+		if o, isOverlay := t.(*envOverlay); isOverlay {
+			res[i] = &envOverlay{env: o.env, values: values}
+			continue
+		}
+		res[i] = &layoutTemplate{Template: t, values: values, moves: moves}
+	}
+	return res, nil
+}
+
+// valuesUnits returns sorted paths of values referenced by templates. Paths nested into other referenced paths
+// are omitted because they are moved together with the parent value.
+func valuesUnits(values helmify.Values, texts []string) []string {
+	refs := map[string]struct{}{}
+	for _, text := range texts {
+		for _, m := range valuesRefRe.FindAllStringSubmatch(text, -1) {
+			if _, ok := getValue(values, strings.Split(m[1], ".")); ok {
+				refs[m[1]] = struct{}{}
+			}
+		}
+	}
+	var res []string
+	for ref := range refs {
+		nested := false
+		for other := range refs {
+			if strings.HasPrefix(ref, other+".") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			res = append(res, ref)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// matchValuesLayout returns new paths of values units by their current paths. Top level keys are matched first by
+// the number of units matching reference paths under the key. Then every unit is matched to the reference path under
+// the matched key sharing the longest path suffix. Ambiguous matches are skipped.
+func matchValuesLayout(reference, values map[string]interface{}, units []string) map[string]string {
+	refTop := map[string][]string{}
+	for _, p := range nodePaths(reference, "") {
+		top, rel := splitTop(p)
+		refTop[top] = append(refTop[top], rel)
+	}
+	genTop := map[string][]string{}
+	for _, u := range units {
+		top, rel := splitTop(u)
+		genTop[top] = append(genTop[top], rel)
+	}
+
+	// match top level keys
+	assigned, used := map[string]string{}, map[string]bool{}
+	for top := range genTop {
+		if _, ok := refTop[top]; ok {
+			assigned[top], used[top] = top, true
+		}
+	}
+	type pair struct {
+		gen, ref string
+		score    int
+	}
+	var pairs []pair
+	for gen, rels := range genTop {
+		if _, ok := assigned[gen]; ok {
+			continue
+		}
+		for ref, refRels := range refTop {
+			if used[ref] {
+				continue
+			}
+			score := 0
+			for _, rel := range rels {
+				if _, ok := bestSuffixMatch(rel, refRels, nil); ok {
+					score++
+				}
+			}
+			if score != 0 {
+				pairs = append(pairs, pair{gen: gen, ref: ref, score: score})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].score != pairs[j].score {
+			return pairs[i].score > pairs[j].score
+		}
+		if pairs[i].gen != pairs[j].gen {
+			return pairs[i].gen < pairs[j].gen
+		}
+		return pairs[i].ref < pairs[j].ref
+	})
+	for _, p := range pairs {
+		if _, ok := assigned[p.gen]; ok || used[p.ref] {
+			continue
+		}
+		assigned[p.gen], used[p.ref] = p.ref, true
+	}
+
+	// match units under matched top level keys
+	res := map[string]string{}
+	taken := map[string][]string{}
+	for _, u := range units {
+		top, rel := splitTop(u)
+		ref, ok := assigned[top]
+		if !ok {
+			continue
+		}
+		target := ref
+		if rel != "" {
+			var candidates []string
+			for _, r := range refTop[ref] {
+				if isMapValue(reference, ref+"."+r) == isMapValue(values, u) {
+					candidates = append(candidates, r)
+				}
+			}
+			match, found := bestSuffixMatch(rel, candidates, taken[ref])
+			if !found {
+				continue
+			}
+			taken[ref] = append(taken[ref], match)
+			target = ref + "." + match
+		}
+		if target != u {
+			res[u] = target
+		}
+	}
+	return res
+}
+
+// bestSuffixMatch returns candidate path sharing the longest non-empty suffix with given path. Candidates with less
+// extra segments win ties. Returns false if there is no or more than one best candidate, or if the best candidate
+// overlaps with taken paths.
+func bestSuffixMatch(path string, candidates, taken []string) (string, bool) {
+	if path == "" {
+		return "", false
+	}
+	segments := strings.Split(path, ".")
+	best, bestSuffix, bestExtra, ambiguous := "", 0, 0, false
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+		cSegments := strings.Split(c, ".")
+		suffix := 0
+		for suffix < len(segments) && suffix < len(cSegments) &&
+			segments[len(segments)-1-suffix] == cSegments[len(cSegments)-1-suffix] {
+			suffix++
+		}
+		if suffix == 0 {
+			continue
+		}
+		extra := len(cSegments) - suffix
+		switch {
+		case suffix > bestSuffix || suffix == bestSuffix && extra < bestExtra:
+			best, bestSuffix, bestExtra, ambiguous = c, suffix, extra, false
+		case suffix == bestSuffix && extra == bestExtra:
+			ambiguous = true
+		}
+	}
+	if best == "" || ambiguous {
+		return "", false
+	}
+	for _, t := range taken {
+		if t == best || strings.HasPrefix(best, t+".") || strings.HasPrefix(t, best+".") {
+			return "", false
+		}
+	}
+	return best, true
+}
+
+// nodePaths returns dot separated paths of all nested values including maps.
+func nodePaths(values map[string]interface{}, prefix string) []string {
+	var res []string
+	for k, v := range values {
+		path := prefix + k
+		res = append(res, path)
+		if m, ok := v.(map[string]interface{}); ok {
+			res = append(res, nodePaths(m, path+".")...)
+		}
+	}
+	return res
+}
+
+func splitTop(path string) (string, string) {
+	top, rel, _ := strings.Cut(path, ".")
+	return top, rel
+}
+
+func isMapValue(values map[string]interface{}, path string) bool {
+	v, _ := getValue(values, strings.Split(path, "."))
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+func getValue(values map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = values
+	for _, p := range path {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// moveValues returns copy of values with values moved from key paths to value paths. Given values are not modified.
+func moveValues(values helmify.Values, moves map[string]string) helmify.Values {
+	res := map[string]interface{}(values)
+	moved := map[string]interface{}{}
+	for _, from := range sortedKeys(moves) {
+		fromPath := strings.Split(from, ".")
+		if v, ok := getValue(res, fromPath); ok {
+			moved[moves[from]] = v
+			res = withoutValue(res, fromPath)
+		}
+	}
+	for to, v := range moved {
+		res = withValue(res, strings.Split(to, "."), v)
+	}
+	return res
+}
+
+// withoutValue returns copy of values without value at given path. Maps emptied by the removal are removed as well.
+func withoutValue(values map[string]interface{}, path []string) map[string]interface{} {
+	res := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		res[k] = v
+	}
+	if len(path) == 1 {
+		delete(res, path[0])
+		return res
+	}
+	nested, ok := res[path[0]].(map[string]interface{})
+	if !ok {
+		return res
+	}
+	nested = withoutValue(nested, path[1:])
+	if len(nested) == 0 {
+		delete(res, path[0])
+	} else {
+		res[path[0]] = nested
+	}
+	return res
+}
+
+// withValue returns copy of values with value set at given path.
+func withValue(values map[string]interface{}, path []string, value interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(values)+1)
+	for k, v := range values {
+		res[k] = v
+	}
+	if len(path) == 1 {
+		res[path[0]] = value
+		return res
+	}
+	nested, _ := res[path[0]].(map[string]interface{})
+	res[path[0]] = withValue(nested, path[1:], value)
+	return res
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// layoutTemplate - template with values references rewritten to reference values layout.
+type layoutTemplate struct {
+	helmify.Template
+	values helmify.Values
+	moves  map[string]string
+}
+
+func (t *layoutTemplate) Values() helmify.Values {
+	return t.values
+}
+
+func (t *layoutTemplate) Write(writer io.Writer) error {
+	var buf bytes.Buffer
+	if err := t.Template.Write(&buf); err != nil {
+		return err
+	}
+	res := valuesRefRe.ReplaceAllStringFunc(buf.String(), func(ref string) string {
+		path := strings.TrimPrefix(ref, ".Values.")
+		for from, to := range t.moves {
+			if path == from || strings.HasPrefix(path, from+".") {
+				return ".Values." + to + strings.TrimPrefix(path, from)
+			}
+		}
+		return ref
+	})
+	_, err := writer.Write([]byte(res))
+	return err
+}
+
+// ValuesSchema - returns JSON schema of the wrapped template with properties moved to reference values layout.
+// Returns nil if the wrapped template has no schema.
+func (t *layoutTemplate) ValuesSchema() map[string]interface{} {
+	s, ok := t.Template.(helmify.ValuesSchema)
+	if !ok {
+		return nil
+	}
+	type movedSchema struct {
+		schema   interface{}
+		required bool
+	}
+	res := map[string]interface{}{"properties": s.ValuesSchema()}
+	moved := map[string]movedSchema{}
+	for _, from := range sortedKeys(t.moves) {
+		var m movedSchema
+		var found bool
+		res, m.schema, m.required, found = withoutSchema(res, strings.Split(from, "."))
+		if found {
+			moved[t.moves[from]] = m
+		}
+	}
+	for to, m := range moved {
+		res = withSchema(res, strings.Split(to, "."), m.schema, m.required)
+	}
+	props, _ := res["properties"].(map[string]interface{})
+	return props
+}
+
+// withoutSchema returns copy of object schema without property schema at given path together with the removed
+// property schema and whether the property was required. Objects emptied by the removal are removed as well.
+func withoutSchema(schema map[string]interface{}, path []string) (map[string]interface{}, interface{}, bool, bool) {
+	props, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		return schema, nil, false, false
+	}
+	prop, ok := props[path[0]]
+	if !ok {
+		return schema, nil, false, false
+	}
+	var removed interface{}
+	var required bool
+	resProps := make(map[string]interface{}, len(props))
+	for k, v := range props {
+		resProps[k] = v
+	}
+	if len(path) == 1 {
+		removed, required = prop, isRequired(schema, path[0])
+		delete(resProps, path[0])
+	} else {
+		nested, isMap := prop.(map[string]interface{})
+		if !isMap {
+			return schema, nil, false, false
+		}
+		var found bool
+		nested, removed, required, found = withoutSchema(nested, path[1:])
+		if !found {
+			return schema, nil, false, false
+		}
+		if nestedProps, _ := nested["properties"].(map[string]interface{}); len(nestedProps) == 0 {
+			delete(resProps, path[0])
+		} else {
+			resProps[path[0]] = nested
+		}
+	}
+	res := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		res[k] = v
+	}
+	res["properties"] = resProps
+	if _, kept := resProps[path[0]]; !kept {
+		setRequired(res, path[0], false)
+	}
+	return res, removed, required, true
+}
+
+// withSchema returns copy of object schema with property schema set at given path. Missing parent objects are added.
+func withSchema(schema map[string]interface{}, path []string, value interface{}, required bool) map[string]interface{} {
+	res := make(map[string]interface{}, len(schema)+1)
+	for k, v := range schema {
+		res[k] = v
+	}
+	props, _ := schema["properties"].(map[string]interface{})
+	resProps := make(map[string]interface{}, len(props)+1)
+	for k, v := range props {
+		resProps[k] = v
+	}
+	res["properties"] = resProps
+	if len(path) == 1 {
+		resProps[path[0]] = value
+		if required {
+			setRequired(res, path[0], true)
+		}
+		return res
+	}
+	nested, _ := resProps[path[0]].(map[string]interface{})
+	if nested == nil {
+		nested = map[string]interface{}{"type": "object"}
+	}
+	resProps[path[0]] = withSchema(nested, path[1:], value, required)
+	return res
+}
+
+func isRequired(schema map[string]interface{}, key string) bool {
+	required, _ := schema["required"].([]interface{})
+	for _, r := range required {
+		if r == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setRequired adds key to or removes key from required properties of given object schema.
+func setRequired(schema map[string]interface{}, key string, required bool) {
+	old, _ := schema["required"].([]interface{})
+	res := make([]interface{}, 0, len(old)+1)
+	for _, r := range old {
+		if r != key {
+			res = append(res, r)
+		}
+	}
+	if required {
+		res = append(res, key)
+	}
+	if len(res) == 0 {
+		delete(schema, "required")
+		return
+	}
+	schema["required"] = res
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const rbacGroup = "rbac.authorization.k8s.io"
+
+// roleKey - identifies Role or ClusterRole referenced by bindings. ClusterRoles have empty namespace.
+type roleKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// mergeRoles removes Roles and ClusterRoles with rules identical to the rules of other Role or ClusterRole of the same
+// kind and namespace and rewrites roleRef of bindings to the kept one. Roles with aggregationRule are never merged.
+// Role and ClusterRole with identical rules are only reported, because a ClusterRole can be bound cluster-wide.
+func (c *appContext) mergeRoles() error {
+	if !c.config.MergeRBACRoles {
+		return nil
+	}
+	var kept []*unstructured.Unstructured
+	replaced := map[roleKey]string{}
+	var objects []*unstructured.Unstructured
+	var fileNames []string
+	for i, obj := range c.objects {
+		if !isRole(obj) {
+			objects = append(objects, obj)
+			fileNames = append(fileNames, c.fileNames[i])
+			continue
+		}
+		if _, aggregated := obj.Object["aggregationRule"]; aggregated {
+			objects = append(objects, obj)
+			fileNames = append(fileNames, c.fileNames[i])
+			continue
+		}
+		var same *unstructured.Unstructured
+		for _, k := range kept {
+			if !reflect.DeepEqual(k.Object["rules"], obj.Object["rules"]) {
+				continue
+			}
+			if k.GetKind() != obj.GetKind() {
+				logrus.WithFields(logrus.Fields{
+					"Kept":  k.GetKind() + "/" + k.GetName(),
+					"Other": obj.GetKind() + "/" + obj.GetName(),
+				}).Info("Role and ClusterRole have identical rules: not merged")
+				continue
+			}
+			if k.GetNamespace() == obj.GetNamespace() {
+				same = k
+				break
+			}
+		}
+		if same == nil {
+			kept = append(kept, obj)
+			objects = append(objects, obj)
+			fileNames = append(fileNames, c.fileNames[i])
+			continue
+		}
+		logrus.WithFields(logrus.Fields{
+			"Kind": obj.GetKind(),
+			"Name": obj.GetName(),
+			"Kept": same.GetName(),
+		}).Info("merging role with identical rules")
+		replaced[roleKey{kind: obj.GetKind(), namespace: obj.GetNamespace(), name: obj.GetName()}] = same.GetName()
+	}
+	for _, obj := range objects {
+		if err := rewriteRoleRef(obj, replaced); err != nil {
+			return err
+		}
+	}
+	c.objects, c.fileNames = objects, fileNames
+	return nil
+}
+
+func isRole(obj *unstructured.Unstructured) bool {
+	gvk := obj.GroupVersionKind()
+	return gvk.Group == rbacGroup && (gvk.Kind == "Role" || gvk.Kind == "ClusterRole")
+}
+
+// rewriteRoleRef points roleRef of RoleBinding or ClusterRoleBinding to the kept role if referenced role was merged.
+func rewriteRoleRef(obj *unstructured.Unstructured, replaced map[roleKey]string) error {
+	gvk := obj.GroupVersionKind()
+	if gvk.Group != rbacGroup || (gvk.Kind != "RoleBinding" && gvk.Kind != "ClusterRoleBinding") {
+		return nil
+	}
+	ref, _, err := unstructured.NestedStringMap(obj.Object, "roleRef")
+	if err != nil {
+		return fmt.Errorf("%w: unable to get roleRef of %s %s", err, gvk.Kind, obj.GetName())
+	}
+	key := roleKey{kind: ref["kind"], name: ref["name"]}
+	if key.kind == "Role" {
+		// RoleBinding can reference only Role from its own namespace
+		key.namespace = obj.GetNamespace()
+	}
+	name, ok := replaced[key]
+	if !ok {
+		return nil
+	}
+	return unstructured.SetNestedField(obj.Object, name, "roleRef", "name")
+}
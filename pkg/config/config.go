@@ -2,6 +2,10 @@ package config
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
+
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/validation"
 )
@@ -9,16 +13,40 @@ import (
 // defaultChartName - default name for a helm chart directory.
 const defaultChartName = "chart"
 
+// Log formats supported by LogFormat config.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Chart types allowed in Chart.yaml 'type' field.
+const (
+	ChartTypeApplication = "application"
+	ChartTypeLibrary     = "library"
+)
+
+// ArtifactHubChangeKinds - kinds of changes allowed in artifacthub.io/changes Chart.yaml annotation.
+var ArtifactHubChangeKinds = map[string]bool{
+	"added": true, "changed": true, "deprecated": true, "removed": true, "fixed": true, "security": true,
+}
+
 // Config for Helmify application.
 type Config struct {
 	// ChartName name of the Helm chart and its base directory where Chart.yaml is located.
 	ChartName string
 	// ChartDir - optional path to chart dir. Full chart path will be: ChartDir/ChartName/Chart.yaml.
 	ChartDir string
+	// ChartType - Chart.yaml type field: application or library. Library chart has no appVersion and crds dir.
+	// Resources of library chart are written as named templates "<ChartName>.<file name>" in templates/_<file name>.tpl.
+	ChartType string
 	// Verbose set true to see WARN and INFO logs.
 	Verbose bool
 	// VeryVerbose set true to see WARN, INFO, and DEBUG logs.
 	VeryVerbose bool
+	// LogLevel - log level: debug, info, warn or error. Overrides Verbose and VeryVerbose when set.
+	LogLevel string
+	// LogFormat - log output format: text or json.
+	LogFormat string
 	// crd-dir set true to enable crd folder.
 	Crd bool
 	// ImagePullSecrets flag
@@ -34,6 +62,81 @@ type Config struct {
 	Files []string
 	// FilesRecursively read Files recursively
 	FilesRecursively bool
+	// Strict fails chart generation if some input resources have no dedicated processor
+	// and would be passed through by the default processor without templating.
+	Strict bool
+	// FailOnWarning - fail chart generation if any warning is reported, e.g. dangling reference or unreadable input file.
+	FailOnWarning bool
+	// Labels - labels added to metadata of all generated resources.
+	Labels map[string]string
+	// Annotations - annotations added to metadata of all generated resources.
+	Annotations map[string]string
+	// PreserveFinalizers - object finalizers to keep in the chart. All other finalizers are stripped.
+	PreserveFinalizers []string
+	// ExtraTemplates - scaffold templates/extra directory for hand-written templates. Helmify never overwrites it.
+	ExtraTemplates bool
+	// MergeInto - update existing chart in place: templates of new resources are added, generated values are merged
+	// into existing values files keeping existing values. Files not produced by the run and files with unchanged content
+	// are left untouched.
+	MergeInto bool
+	// Subcharts - names of local subcharts from charts directory added to Chart.yaml dependencies.
+	Subcharts []string
+	// ConvertReplicationControllers - convert legacy ReplicationController resources to Deployment.
+	ConvertReplicationControllers bool
+	// IngressDomain - base domain of Ingress hosts moved to global.domain value. Hosts are rendered as <subdomain>.<domain>.
+	IngressDomain string
+	// IngressBackendPortNames - rewrite numeric Ingress backend ports to port names of chart Services.
+	IngressBackendPortNames bool
+	// IngressExtraAnnotations - render Ingress annotations from values merged with <name>.ingress.extraAnnotations value,
+	// so users can add annotations without respecifying defaults.
+	IngressExtraAnnotations bool
+	// ReferenceReport - path of JSON report listing ConfigMaps, Secrets, PersistentVolumeClaims and ServiceAccounts
+	// referenced by chart workloads. Report is not written if empty.
+	ReferenceReport string
+	// ValuesIndent - indentation of values.yaml nested mappings. Defaults to 2.
+	ValuesIndent int
+	// ValuesIndentSequences - indent values.yaml sequence items under parent key.
+	ValuesIndentSequences bool
+	// ValuesQuote - quote all values.yaml string values with 'double' or 'single' quotes.
+	ValuesQuote string
+	// ValuesLayout - reference values.yaml file. Generated values are moved to matching locations of the reference file
+	// key structure. Values without a match keep the default location.
+	ValuesLayout string
+	// EnvLabel - label key marking environment of input resources, e.g. 'env'. Environment variants of the same resource
+	// are templated once and their values differences are written to values-<env>.yaml overlays.
+	EnvLabel string
+	// ImageArch - CPU architecture of image repositories, e.g. 'nginx' -> 'amd64'. Pods with all images of the same known
+	// architecture get kubernetes.io/arch nodeSelector default in values.
+	ImageArch map[string]string
+	// TolerationFields - move fields of every pod toleration to separate values instead of the whole tolerations list.
+	TolerationFields bool
+	// RBACRulesValues - externalize Role and ClusterRole rules into values.yaml.
+	RBACRulesValues bool
+	// CustomResourceValues - move spec fields of custom resources described by chart CRDs to values according to CRD
+	// OpenAPI schema and write matching values.schema.json.
+	CustomResourceValues bool
+	// BlueGreenLabel - pod label key of blue-green deployment color, e.g. 'color'. Service selectors having the label
+	// select pods with the label set to activeColor value, so switching the value routes Services and Ingresses to another color.
+	BlueGreenLabel string
+	// ServiceAnnotationValues - regular expressions of Service annotation keys. Values of matching annotations are moved
+	// to <service>.annotations value, e.g. cloud load balancer certificate or target group references.
+	ServiceAnnotationValues []string
+	// CorrelationLabels - label keys identifying workload pods. Used to choose the workload fronted by a Service when
+	// Service selector matches pods of several workloads. Defaults to app and app.kubernetes.io/name.
+	CorrelationLabels []string
+	// RequiredValues - dot separated values paths of ConfigMap data, e.g. 'myAppConfig.dbHost', having no safe default.
+	// Their values default to empty and templates fail to render until they are set.
+	RequiredValues []string
+	// MergeRBACRoles - merge Roles and ClusterRoles with identical rules into one and rewrite bindings to reference it.
+	MergeRBACRoles bool
+	// ArtifactHubImages - set artifacthub.io/images Chart.yaml annotation listing container images from the input.
+	ArtifactHubImages bool
+	// ArtifactHubChanges - changes in <kind>=<description> format listed in artifacthub.io/changes Chart.yaml annotation.
+	ArtifactHubChanges []string
+	// SetValues - values overrides in helm '--set' format applied on top of generated values.yaml.
+	SetValues []string
+	// SetStringValues - values overrides in helm '--set-string' format. Values are always set as strings.
+	SetStringValues []string
 }
 
 func (c *Config) Validate() error {
@@ -48,5 +151,45 @@ func (c *Config) Validate() error {
 		}
 		return fmt.Errorf("invalid chart name %s", c.ChartName)
 	}
+	if c.LogLevel != "" {
+		if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+			return fmt.Errorf("%w: invalid log level", err)
+		}
+	}
+	switch c.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("invalid log format %q: must be %s or %s", c.LogFormat, LogFormatText, LogFormatJSON)
+	}
+	if c.ValuesIndent != 0 && (c.ValuesIndent < 2 || c.ValuesIndent > 9) {
+		return fmt.Errorf("invalid values indent %d: must be between 2 and 9", c.ValuesIndent)
+	}
+	switch c.ValuesQuote {
+	case "", yamlformat.QuoteDouble, yamlformat.QuoteSingle:
+	default:
+		return fmt.Errorf("invalid values quote %q: must be %s or %s", c.ValuesQuote, yamlformat.QuoteDouble, yamlformat.QuoteSingle)
+	}
+	for _, expr := range c.ServiceAnnotationValues {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("%w: invalid service annotation expression %q", err, expr)
+		}
+	}
+	for _, change := range c.ArtifactHubChanges {
+		kind, description, _ := strings.Cut(change, "=")
+		if !ArtifactHubChangeKinds[kind] || description == "" {
+			return fmt.Errorf("invalid artifacthub change %q: must be <kind>=<description> with kind one of added, changed, deprecated, removed, fixed, security", change)
+		}
+	}
+	switch c.ChartType {
+	case "":
+		c.ChartType = ChartTypeApplication
+	case ChartTypeApplication:
+	case ChartTypeLibrary:
+		if c.Crd {
+			return fmt.Errorf("crds dir is not supported for %s chart", ChartTypeLibrary)
+		}
+	default:
+		return fmt.Errorf("invalid chart type %q: must be %s or %s", c.ChartType, ChartTypeApplication, ChartTypeLibrary)
+	}
 	return nil
 }
@@ -47,4 +47,55 @@ func TestConfig_Validate(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, "test", c.ChartName)
 	})
+	t.Run("chart type", func(t *testing.T) {
+		c := &Config{}
+		assert.NoError(t, c.Validate())
+		assert.Equal(t, ChartTypeApplication, c.ChartType)
+
+		c = &Config{ChartType: ChartTypeLibrary}
+		assert.NoError(t, c.Validate())
+		assert.Equal(t, ChartTypeLibrary, c.ChartType)
+
+		c = &Config{ChartType: "plugin"}
+		assert.ErrorContains(t, c.Validate(), `invalid chart type "plugin"`)
+
+		c = &Config{ChartType: ChartTypeLibrary, Crd: true}
+		assert.Error(t, c.Validate())
+	})
+	t.Run("logging", func(t *testing.T) {
+		c := &Config{LogLevel: "debug", LogFormat: LogFormatJSON}
+		assert.NoError(t, c.Validate())
+
+		c = &Config{LogLevel: "loud"}
+		assert.ErrorContains(t, c.Validate(), "invalid log level")
+
+		c = &Config{LogFormat: "xml"}
+		assert.ErrorContains(t, c.Validate(), `invalid log format "xml"`)
+	})
+	t.Run("values style", func(t *testing.T) {
+		c := &Config{ValuesIndent: 4, ValuesQuote: "double"}
+		assert.NoError(t, c.Validate())
+
+		c = &Config{ValuesIndent: 1}
+		assert.ErrorContains(t, c.Validate(), "invalid values indent 1")
+
+		c = &Config{ValuesQuote: "backtick"}
+		assert.ErrorContains(t, c.Validate(), `invalid values quote "backtick"`)
+	})
+	t.Run("service annotation expressions", func(t *testing.T) {
+		c := &Config{ServiceAnnotationValues: []string{"aws-load-balancer-ssl-cert$"}}
+		assert.NoError(t, c.Validate())
+
+		c = &Config{ServiceAnnotationValues: []string{"aws-(load"}}
+		assert.ErrorContains(t, c.Validate(), `invalid service annotation expression "aws-(load"`)
+	})
+	t.Run("artifacthub changes", func(t *testing.T) {
+		c := &Config{ArtifactHubChanges: []string{"added=Redis cache", "fixed=Probe port = 8080"}}
+		assert.NoError(t, c.Validate())
+
+		c = &Config{ArtifactHubChanges: []string{"improved=Faster start"}}
+		assert.ErrorContains(t, c.Validate(), `invalid artifacthub change "improved=Faster start"`)
+		c = &Config{ArtifactHubChanges: []string{"added"}}
+		assert.ErrorContains(t, c.Validate(), `invalid artifacthub change "added"`)
+	})
 }
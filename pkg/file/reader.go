@@ -1,11 +1,13 @@
 package file
 
 import (
-	"github.com/sirupsen/logrus"
 	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
+
+	"github.com/arttor/helmify/pkg/warning"
+	"github.com/sirupsen/logrus"
 )
 
 func Walk(paths []string, recursively bool, walkFunc func(filename string, r io.Reader)) {
@@ -13,33 +15,29 @@ func Walk(paths []string, recursively bool, walkFunc func(filename string, r io.
 	for _, path := range paths {
 		info, err := os.Stat(path)
 		if err != nil {
-			logrus.Warnf("no such file or directory %q: %v", path, err)
+			warning.Report(logrus.WithField("Path", path).WithError(err), "no such file or directory")
 			continue
 		}
 		// handle single file file:
 		if !info.IsDir() {
 			file, err := os.Open(path)
 			if err != nil {
-				logrus.Warnf("unable to open file %q: %v", file.Name(), err)
+				warning.Report(logrus.WithField("File", path).WithError(err), "unable to open file")
 				continue
 			}
 			walkFunc(info.Name(), file)
 			err = file.Close()
 			if err != nil {
-				logrus.Warnf("unable to close file %q: %v", file.Name(), err)
+				warning.Report(logrus.WithField("File", file.Name()).WithError(err), "unable to close file")
 			}
 			continue
 		}
 		// handle directory non-recursively:
 		if !recursively {
-			dir, err := os.Open(path)
-			if err != nil {
-				logrus.Warnf("unable to open directory %q: %v", dir.Name(), err)
-				continue
-			}
-			files, err := dir.ReadDir(0)
+			// files are read in lexical order, so the chart does not depend on directory order of the file system
+			files, err := os.ReadDir(path)
 			if err != nil {
-				logrus.Warnf("unable to read directory %q: %v", dir.Name(), err)
+				warning.Report(logrus.WithField("Path", path).WithError(err), "unable to read directory")
 				continue
 			}
 			for _, f := range files {
@@ -48,13 +46,13 @@ func Walk(paths []string, recursively bool, walkFunc func(filename string, r io.
 				}
 				file, err := os.Open(filepath.Join(path, f.Name()))
 				if err != nil {
-					logrus.Warnf("unable to open file %q: %v", file.Name(), err)
+					warning.Report(logrus.WithField("File", filepath.Join(path, f.Name())).WithError(err), "unable to open file")
 					continue
 				}
 				walkFunc(f.Name(), file)
 				err = file.Close()
 				if err != nil {
-					logrus.Warnf("unable to close file %q: %v", file.Name(), err)
+					warning.Report(logrus.WithField("File", file.Name()).WithError(err), "unable to close file")
 				}
 				continue
 			}
@@ -75,12 +73,12 @@ func Walk(paths []string, recursively bool, walkFunc func(filename string, r io.
 			walkFunc(d.Name(), file)
 			err = file.Close()
 			if err != nil {
-				logrus.Warnf("unable to close file %q: %v", file.Name(), err)
+				warning.Report(logrus.WithField("File", file.Name()).WithError(err), "unable to close file")
 			}
 			return nil
 		})
 		if err != nil {
-			logrus.Warnf("unable to open %q: %v", info.Name(), err)
+			warning.Report(logrus.WithField("Path", info.Name()).WithError(err), "unable to open")
 			continue
 		}
 	}
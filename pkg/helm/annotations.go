@@ -0,0 +1,66 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/config"
+	"sigs.k8s.io/yaml"
+)
+
+// SetChartAnnotations - sets given annotations in Chart.yaml of the chart. Other annotations of existing
+// annotations block are kept. The block is rewritten in place, the rest of Chart.yaml is left as it is.
+func SetChartAnnotations(conf config.Config, annotations map[string]string) error {
+	if len(annotations) == 0 {
+		return nil
+	}
+	file := filepath.Join(conf.ChartDir, conf.ChartName, "Chart.yaml")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("%w: unable to read %s", err, file)
+	}
+	var chart struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err = yaml.Unmarshal(data, &chart); err != nil {
+		return fmt.Errorf("%w: unable to parse %s", err, file)
+	}
+	if chart.Annotations == nil {
+		chart.Annotations = map[string]string{}
+	}
+	for k, v := range annotations {
+		chart.Annotations[k] = v
+	}
+	block, err := yaml.Marshal(map[string]interface{}{"annotations": chart.Annotations})
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal Chart.yaml annotations", err)
+	}
+	return writeFile(file, replaceTopLevelBlock(data, "annotations", block), conf.MergeInto)
+}
+
+// replaceTopLevelBlock - replaces top level yaml block with given key by given block or appends the block if yaml
+// has no such key. Block consists of the key line and the following indented lines.
+func replaceTopLevelBlock(data []byte, key string, block []byte) []byte {
+	lines := strings.SplitAfter(string(data), "\n")
+	start := -1
+	for i, line := range lines {
+		if strings.HasPrefix(line, key+":") {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		res := string(data)
+		if res != "" && !strings.HasSuffix(res, "\n") {
+			res += "\n"
+		}
+		return []byte(res + string(block))
+	}
+	end := start + 1
+	for end < len(lines) && (strings.HasPrefix(lines[end], " ") || strings.HasPrefix(lines[end], "\t")) {
+		end++
+	}
+	return []byte(strings.Join(lines[:start], "") + string(block) + strings.Join(lines[end:], ""))
+}
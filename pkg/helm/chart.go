@@ -1,25 +1,30 @@
 package helm
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/arttor/helmify/pkg/cluster"
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/helmify"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
 
 	"github.com/sirupsen/logrus"
-
-	"sigs.k8s.io/yaml"
 )
 
 // NewOutput creates interface to dump processed input to filesystem in Helm chart format.
-func NewOutput() helmify.Output {
-	return &output{}
+// Given values transforms are applied in order to the values merged from all templates. Transforms run before
+// '-set' and '-set-string' overrides, so overrides always address the final values structure.
+func NewOutput(transforms ...helmify.ValuesTransform) helmify.Output {
+	return &output{transforms: transforms}
 }
 
-type output struct{}
+type output struct {
+	transforms []helmify.ValuesTransform
+}
 
 // Create a helm chart in the current directory:
 // chartName/
@@ -27,12 +32,18 @@ type output struct{}
 //	├── .helmignore   	# Contains patterns to ignore when packaging Helm charts.
 //	├── Chart.yaml    	# Information about your chart
 //	├── values.yaml   	# The default values for your templates
+//	├── values-<env>.yaml	# Environment values overlays, written if EnvLabel is configured
+//	├── values.schema.json	# JSON schema of values, written if some templates provide it
 //	└── templates/    	# The template files
 //	    └── _helpers.tp   # Helm default template partials
 //
 // Overwrites existing values.yaml and templates in templates dir on every run.
-func (o output) Create(chartDir, chartName string, crd bool, certManagerAsSubchart bool, certManagerVersion string, templates []helmify.Template, filenames []string) error {
-	err := initChartDir(chartDir, chartName, crd, certManagerAsSubchart, certManagerVersion)
+// Library chart templates are written as named templates into templates/_<name>.tpl files.
+// With MergeInto configured, existing chart is updated in place: generated values are merged into existing
+// values files and files with unchanged content are not rewritten.
+func (o output) Create(conf config.Config, templates []helmify.Template, filenames []string) error {
+	chartDir, chartName, crd, certManagerAsSubchart := conf.ChartDir, conf.ChartName, conf.Crd, conf.CertManagerAsSubchart
+	err := initChartDir(conf)
 	if err != nil {
 		return err
 	}
@@ -40,7 +51,25 @@ func (o output) Create(chartDir, chartName string, crd bool, certManagerAsSubcha
 	files := map[string][]helmify.Template{}
 	values := helmify.Values{}
 	values[cluster.DomainKey] = cluster.DefaultDomain
+	values["commonLabels"] = map[string]interface{}{}
+	values["commonAnnotations"] = map[string]interface{}{}
+	overlays := map[string]helmify.Values{}
+	valuesSchema := map[string]interface{}{}
 	for i, template := range templates {
+		if overlay, ok := template.(helmify.ValuesOverlay); ok {
+			envValues := overlays[overlay.Env()]
+			if envValues == nil {
+				envValues = helmify.Values{}
+			}
+			if err = envValues.Merge(overlay.Values()); err != nil {
+				return err
+			}
+			overlays[overlay.Env()] = envValues
+			continue
+		}
+		if s, ok := template.(helmify.ValuesSchema); ok {
+			mergeSchemaProperties(valuesSchema, s.ValuesSchema())
+		}
 		file := files[filenames[i]]
 		file = append(file, template)
 		files[filenames[i]] = file
@@ -49,21 +78,70 @@ func (o output) Create(chartDir, chartName string, crd bool, certManagerAsSubcha
 			return err
 		}
 	}
+	for _, transform := range o.transforms {
+		values = transform(values)
+	}
+	// values from command line override generated defaults
+	for _, set := range conf.SetValues {
+		if err = values.Set(set); err != nil {
+			return err
+		}
+	}
+	for _, set := range conf.SetStringValues {
+		if err = values.SetString(set); err != nil {
+			return err
+		}
+	}
 	cDir := filepath.Join(chartDir, chartName)
+	if conf.MergeInto {
+		values, err = mergeExistingValues(filepath.Join(cDir, "values.yaml"), values)
+		if err != nil {
+			return err
+		}
+		for env, envValues := range overlays {
+			overlays[env], err = mergeExistingValues(filepath.Join(cDir, "values-"+env+".yaml"), envValues)
+			if err != nil {
+				return err
+			}
+		}
+	}
 	for filename, tpls := range files {
-		err = overwriteTemplateFile(filename, cDir, crd, tpls)
+		define := ""
+		if conf.ChartType == config.ChartTypeLibrary {
+			filename, define = libraryTemplateFile(chartName, filename)
+		}
+		err = overwriteTemplateFile(filename, cDir, crd, conf.MergeInto, define, tpls)
 		if err != nil {
 			return err
 		}
 	}
-	err = overwriteValuesFile(cDir, values, certManagerAsSubchart)
+	style := yamlformat.Style{Indent: conf.ValuesIndent, IndentSequences: conf.ValuesIndentSequences, Quote: conf.ValuesQuote}
+	err = overwriteValuesFile(cDir, values, certManagerAsSubchart, conf.MergeInto, style)
 	if err != nil {
 		return err
 	}
+	for env, envValues := range overlays {
+		err = overwriteOverlayFile(cDir, env, envValues, conf.MergeInto, style)
+		if err != nil {
+			return err
+		}
+	}
+	if len(valuesSchema) != 0 {
+		return overwriteValuesSchemaFile(cDir, valuesSchema, conf.MergeInto)
+	}
 	return nil
 }
 
-func overwriteTemplateFile(filename, chartDir string, crd bool, templates []helmify.Template) error {
+// libraryTemplateFile returns name of library chart template file and name of the named template wrapping content of
+// the given manifest template file. Helm doesn't render manifests of library charts, so resources are exposed to
+// including charts as named templates, e.g. deployment.yaml becomes "<chartName>.deployment" define in _deployment.tpl.
+func libraryTemplateFile(chartName, filename string) (string, string) {
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return "_" + name + ".tpl", chartName + "." + name
+}
+
+// overwriteTemplateFile writes templates into the file. Content is wrapped into named template if define is not empty.
+func overwriteTemplateFile(filename, chartDir string, crd, merge bool, define string, templates []helmify.Template) error {
 	// pull in crd-dir setting and siphon crds into folder
 	var subdir string
 	if strings.Contains(filename, "crd") && crd {
@@ -79,29 +157,45 @@ func overwriteTemplateFile(filename, chartDir string, crd bool, templates []helm
 		subdir = "templates"
 	}
 	file := filepath.Join(chartDir, subdir, filename)
-	f, err := os.OpenFile(file, os.O_APPEND|os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return fmt.Errorf("%w: unable to open %s", err, file)
+	buf := bytes.Buffer{}
+	if define != "" {
+		buf.WriteString(fmt.Sprintf("{{- define %q }}\n", define))
 	}
-	defer f.Close()
 	for i, t := range templates {
 		logrus.WithField("file", file).Debug("writing a template into")
-		err = t.Write(f)
+		err := t.Write(&buf)
 		if err != nil {
 			return fmt.Errorf("%w: unable to write into %s", err, file)
 		}
 		if i != len(templates)-1 {
-			_, err = f.Write([]byte("\n---\n"))
-			if err != nil {
-				return fmt.Errorf("%w: unable to write into %s", err, file)
-			}
+			buf.WriteString("\n---\n")
 		}
 	}
+	if define != "" {
+		buf.WriteString("\n{{- end }}\n")
+	}
+	return writeFile(file, buf.Bytes(), merge)
+}
+
+// writeFile - writes data into file. In merge mode file is not rewritten if its content is unchanged.
+func writeFile(file string, data []byte, merge bool) error {
+	existing, err := os.ReadFile(file)
+	switch {
+	case err == nil && merge && bytes.Equal(existing, data):
+		logrus.WithField("file", file).Info("unchanged")
+		return nil
+	case err != nil && !os.IsNotExist(err):
+		return fmt.Errorf("%w: unable to read %s", err, file)
+	}
+	err = os.WriteFile(file, data, 0600)
+	if err != nil {
+		return fmt.Errorf("%w: unable to write %s", err, file)
+	}
 	logrus.WithField("file", file).Info("overwritten")
 	return nil
 }
 
-func overwriteValuesFile(chartDir string, values helmify.Values, certManagerAsSubchart bool) error {
+func overwriteValuesFile(chartDir string, values helmify.Values, certManagerAsSubchart, merge bool, style yamlformat.Style) error {
 	if certManagerAsSubchart {
 		_, err := values.Add(true, "certmanager", "installCRDs")
 		if err != nil {
@@ -113,16 +207,18 @@ func overwriteValuesFile(chartDir string, values helmify.Values, certManagerAsSu
 			return fmt.Errorf("%w: unable to add cert-manager.enabled", err)
 		}
 	}
-	res, err := yaml.Marshal(values)
+	res, err := yamlformat.MarshalStyle(values, style)
 	if err != nil {
 		return fmt.Errorf("%w: unable to write marshal values.yaml", err)
 	}
 
-	file := filepath.Join(chartDir, "values.yaml")
-	err = os.WriteFile(file, res, 0600)
+	return writeFile(filepath.Join(chartDir, "values.yaml"), res, merge)
+}
+
+func overwriteOverlayFile(chartDir, env string, values helmify.Values, merge bool, style yamlformat.Style) error {
+	res, err := yamlformat.MarshalStyle(values, style)
 	if err != nil {
-		return fmt.Errorf("%w: unable to write values.yaml", err)
+		return fmt.Errorf("%w: unable to marshal %s environment values", err, env)
 	}
-	logrus.WithField("file", file).Info("overwritten")
-	return nil
+	return writeFile(filepath.Join(chartDir, "values-"+env+".yaml"), res, merge)
 }
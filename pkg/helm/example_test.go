@@ -0,0 +1,67 @@
+package helm_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helm"
+	"github.com/arttor/helmify/pkg/helmify"
+)
+
+// replicasDefault sets replicas of every workload to 2 unless replicas are already set.
+func replicasDefault(values helmify.Values) helmify.Values {
+	for _, v := range values {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, isWorkload := obj["podAnnotations"]; !isWorkload {
+			continue
+		}
+		if _, ok = obj["replicas"]; !ok {
+			obj["replicas"] = int64(2)
+		}
+	}
+	return values
+}
+
+func ExampleNewOutput() {
+	dir, _ := os.MkdirTemp("", "helmify")
+	defer os.RemoveAll(dir)
+
+	out := helm.NewOutput(replicasDefault)
+	err := out.Create(config.Config{ChartName: "example", ChartDir: dir}, []helmify.Template{
+		valuesOnly{"web": map[string]interface{}{"podAnnotations": map[string]interface{}{}}},
+	}, []string{"web.yaml"})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	values, _ := os.ReadFile(filepath.Join(dir, "example", "values.yaml"))
+	fmt.Print(string(values))
+	// Output:
+	// commonAnnotations: {}
+	// commonLabels: {}
+	// kubernetesClusterDomain: cluster.local
+	// web:
+	//   podAnnotations: {}
+	//   replicas: 2
+}
+
+// valuesOnly - template with values and empty content.
+type valuesOnly helmify.Values
+
+func (v valuesOnly) Filename() string {
+	return "web.yaml"
+}
+
+func (v valuesOnly) Values() helmify.Values {
+	return helmify.Values(v)
+}
+
+func (v valuesOnly) Write(_ io.Writer) error {
+	return nil
+}
@@ -8,7 +8,9 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/sirupsen/logrus"
+	"sigs.k8s.io/yaml"
 )
 
 const helmIgnore = `# Patterns to ignore when building packages.
@@ -88,6 +90,74 @@ app.kubernetes.io/name: {{ include "<CHARTNAME>.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
 
+{{/*
+Object labels: chart labels with object labels and commonLabels value merged. Keys present in several sources are
+rendered once: chart labels take precedence over object labels and object labels over commonLabels.
+Usage: include "<CHARTNAME>.objectLabels" (dict "labels" (dict "<key>" "<value>") "context" $)
+*/}}
+{{- define "<CHARTNAME>.objectLabels" -}}
+{{- toYaml (merge (include "<CHARTNAME>.labels" .context | fromYaml) (deepCopy (.labels | default dict)) (.context.Values.commonLabels | default dict)) }}
+{{- end }}
+
+{{/*
+Annotations from commonAnnotations value added to all chart objects.
+*/}}
+{{- define "<CHARTNAME>.commonAnnotations" -}}
+{{- with .Values.commonAnnotations }}
+{{- toYaml . }}
+{{- end }}
+{{- end }}
+
+{{/*
+Object annotations from values with commonAnnotations merged. Optional extra annotations take precedence over both.
+Usage: include "<CHARTNAME>.annotations" (dict "annotations" .Values.<object>.annotations "extra" .Values.<object>.extraAnnotations "context" $)
+*/}}
+{{- define "<CHARTNAME>.annotations" -}}
+{{- $annotations := merge (deepCopy (.extra | default dict)) (deepCopy (.annotations | default dict)) (.context.Values.commonAnnotations | default dict) }}
+{{- if $annotations }}
+{{- toYaml $annotations }}
+{{- else }}
+{}
+{{- end }}
+{{- end }}
+
+{{/*
+Pod annotations: chart-wide global.podAnnotations with workload podAnnotations merged on top.
+Usage: include "<CHARTNAME>.podAnnotations" (dict "annotations" .Values.<workload>.podAnnotations "context" $)
+*/}}
+{{- define "<CHARTNAME>.podAnnotations" -}}
+{{- $annotations := merge (deepCopy (.annotations | default dict)) (.context.Values.global.podAnnotations | default dict) }}
+{{- if $annotations }}
+{{- toYaml $annotations }}
+{{- else }}
+{}
+{{- end }}
+{{- end }}
+
+{{/*
+Container resources: requests and limits are rendered independently. Set one of them to null to omit it.
+*/}}
+{{- define "<CHARTNAME>.resources" -}}
+{{- $resources := dict }}
+{{- range $key, $val := . }}
+{{- if $val }}
+{{- $_ := set $resources $key $val }}
+{{- end }}
+{{- end }}
+{{- toYaml $resources }}
+{{- end }}
+
+{{/*
+Ingress service backend port: 'name' for string port value and 'number' otherwise.
+*/}}
+{{- define "<CHARTNAME>.ingressBackendPort" -}}
+{{- if kindIs "string" . }}
+{{- dict "name" . | toJson }}
+{{- else }}
+{{- dict "number" . | toJson }}
+{{- end }}
+{{- end }}
+
 {{/*
 Create the name of the service account to use
 */}}
@@ -111,44 +181,85 @@ description: A Helm chart for Kubernetes
 # Library charts provide useful utilities or functions for the chart developer. They're included as
 # a dependency of application charts to inject those utilities and functions into the rendering
 # pipeline. Library charts do not define any templates and therefore cannot be deployed.
-type: application
+type: %s
 # This is the chart version. This version number should be incremented each time you make changes
 # to the chart and its templates, including the app version.
 # Versions are expected to follow Semantic Versioning (https://semver.org/)
 version: 0.1.0
-# This is the version number of the application being deployed. This version number should be
+`
+
+// appVersion - not generated for library charts because they are not deployed.
+const appVersion = `# This is the version number of the application being deployed. This version number should be
 # incremented each time you make changes to the application. Versions are not expected to
 # follow Semantic Versioning. They should reflect the version the application is using.
 # It is recommended to use it with quotes.
 appVersion: "0.1.0"
 `
 
-const certManagerDependencies = `
-dependencies:
-  - name: cert-manager
+const certManagerDependency = `  - name: cert-manager
     repository: https://charts.jetstack.io
     condition: certmanager.enabled
     alias: certmanager
     version: %q
 `
 
+// localDependency - subchart from charts directory. Referenced with file:// to be installable without 'helm dependency build'.
+const localDependency = `  - name: %[1]s
+    repository: "file://charts/%[1]s"
+    version: %[2]q
+`
+
+// extraTemplatesDir - templates subdirectory for hand-written templates.
+const extraTemplatesDir = "extra"
+
 var chartName = regexp.MustCompile("^[a-zA-Z0-9._-]+$")
 
 const maxChartNameLength = 250
 
 // initChartDir - creates Helm chart structure in chartName directory if not presented.
-func initChartDir(chartDir, chartName string, crd bool, certManagerAsSubchart bool, certManagerVersion string) error {
-	if err := validateChartName(chartName); err != nil {
+func initChartDir(conf config.Config) error {
+	if err := validateChartName(conf.ChartName); err != nil {
 		return err
 	}
 
-	cDir := filepath.Join(chartDir, chartName)
-	_, err := os.Stat(filepath.Join(cDir, "Chart.yaml"))
+	cDir := filepath.Join(conf.ChartDir, conf.ChartName)
+	subcharts, err := loadSubcharts(cDir, conf.Subcharts)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stat(filepath.Join(cDir, "Chart.yaml"))
+	if os.IsNotExist(err) && conf.MergeInto {
+		return fmt.Errorf("unable to merge into %s: Chart.yaml not found", cDir)
+	}
 	if os.IsNotExist(err) {
-		return createCommonFiles(chartDir, chartName, crd, certManagerAsSubchart, certManagerVersion)
+		err = createCommonFiles(conf, subcharts)
+	} else if err == nil {
+		logrus.Info("Skip creating Chart skeleton: Chart.yaml already exists.")
 	}
-	logrus.Info("Skip creating Chart skeleton: Chart.yaml already exists.")
-	return err
+	if err != nil || !conf.ExtraTemplates {
+		return err
+	}
+	return createExtraTemplatesDir(cDir)
+}
+
+// createExtraTemplatesDir - creates templates/extra directory for hand-written templates if not presented.
+// Generated templates are written into templates dir only, so the directory content is kept on regeneration.
+func createExtraTemplatesDir(cDir string) error {
+	dir := filepath.Join(cDir, "templates", extraTemplatesDir)
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	err := os.MkdirAll(dir, 0750)
+	if err != nil {
+		return fmt.Errorf("%w: unable create %s dir", err, dir)
+	}
+	file := filepath.Join(dir, ".gitkeep")
+	err = os.WriteFile(file, nil, 0640)
+	if err != nil {
+		return fmt.Errorf("%w: unable to create %s", err, file)
+	}
+	logrus.WithField("file", file).Info("created")
+	return nil
 }
 
 func validateChartName(name string) error {
@@ -161,8 +272,37 @@ func validateChartName(name string) error {
 	return nil
 }
 
-func createCommonFiles(chartDir, chartName string, crd bool, certManagerAsSubchart bool, certManagerVersion string) error {
-	cDir := filepath.Join(chartDir, chartName)
+// subchart - local chart located in the charts directory of generated chart.
+type subchart struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// loadSubcharts - reads name and version of local subcharts from charts/<name>/Chart.yaml.
+func loadSubcharts(cDir string, names []string) ([]subchart, error) {
+	var res []subchart
+	for _, name := range names {
+		chartFile := filepath.Join(cDir, "charts", name, "Chart.yaml")
+		data, err := os.ReadFile(chartFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to read subchart %s", err, name)
+		}
+		var sc subchart
+		if err = yaml.Unmarshal(data, &sc); err != nil {
+			return nil, fmt.Errorf("%w: unable to parse %s", err, chartFile)
+		}
+		if sc.Version == "" {
+			return nil, fmt.Errorf("subchart %s: version is not set in %s", name, chartFile)
+		}
+		sc.Name = name
+		res = append(res, sc)
+	}
+	return res, nil
+}
+
+func createCommonFiles(conf config.Config, subcharts []subchart) error {
+	chartName, crd := conf.ChartName, conf.Crd
+	cDir := filepath.Join(conf.ChartDir, chartName)
 	err := os.MkdirAll(filepath.Join(cDir, "templates"), 0750)
 	if err != nil {
 		return fmt.Errorf("%w: unable create chart/templates dir", err)
@@ -183,18 +323,31 @@ func createCommonFiles(chartDir, chartName string, crd bool, certManagerAsSubcha
 			logrus.WithField("file", file).Info("created")
 		}
 	}
-	createFile(chartYAML(chartName, certManagerAsSubchart, certManagerVersion), cDir, "Chart.yaml")
+	createFile(chartYAML(conf, subcharts), cDir, "Chart.yaml")
 	createFile([]byte(helmIgnore), cDir, ".helmignore")
 	createFile(helpersYAML(chartName), cDir, "templates", "_helpers.tpl")
 	return err
 }
 
-func chartYAML(appName string, certManagerAsSubchart bool, certManagerVersion string) []byte {
-	chartFile := defaultChartfile
-	if certManagerAsSubchart {
-		chartFile += fmt.Sprintf(certManagerDependencies, certManagerVersion)
+func chartYAML(conf config.Config, subcharts []subchart) []byte {
+	chartType := conf.ChartType
+	if chartType == "" {
+		chartType = config.ChartTypeApplication
+	}
+	chartFile := fmt.Sprintf(defaultChartfile, conf.ChartName, chartType)
+	if chartType != config.ChartTypeLibrary {
+		chartFile += appVersion
+	}
+	if conf.CertManagerAsSubchart || len(subcharts) != 0 {
+		chartFile += "\ndependencies:\n"
+	}
+	if conf.CertManagerAsSubchart {
+		chartFile += fmt.Sprintf(certManagerDependency, conf.CertManagerVersion)
+	}
+	for _, sc := range subcharts {
+		chartFile += fmt.Sprintf(localDependency, sc.Name, sc.Version)
 	}
-	return []byte(fmt.Sprintf(chartFile, appName))
+	return []byte(chartFile)
 }
 
 func helpersYAML(chartName string) []byte {
@@ -0,0 +1,31 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+
+	"dario.cat/mergo"
+	"github.com/arttor/helmify/pkg/helmify"
+	"sigs.k8s.io/yaml"
+)
+
+// mergeExistingValues - reads values file of existing chart and updates it with generated values.
+// Existing content is left untouched: values edited by chart maintainer take precedence over generated defaults,
+// only keys absent in existing file are added from generated values.
+func mergeExistingValues(file string, values helmify.Values) (helmify.Values, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return values, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to read %s", err, file)
+	}
+	existing := helmify.Values{}
+	if err = yaml.Unmarshal(data, &existing); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse %s", err, file)
+	}
+	if err = mergo.Merge(&existing, values); err != nil {
+		return nil, fmt.Errorf("%w: unable to merge %s", err, file)
+	}
+	return existing, nil
+}
@@ -0,0 +1,45 @@
+package helm
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// mergeSchemaProperties merges JSON schema properties from src into dst. Object schemas of the same property are
+// merged recursively, so templates can describe different values of the same key.
+func mergeSchemaProperties(dst, src map[string]interface{}) {
+	for k, srcVal := range src {
+		dstSchema, dstOk := dst[k].(map[string]interface{})
+		srcSchema, srcOk := srcVal.(map[string]interface{})
+		if !dstOk || !srcOk {
+			dst[k] = srcVal
+			continue
+		}
+		dstProps, dstOk := dstSchema["properties"].(map[string]interface{})
+		srcProps, srcOk := srcSchema["properties"].(map[string]interface{})
+		if !dstOk || !srcOk {
+			dst[k] = srcVal
+			continue
+		}
+		mergeSchemaProperties(dstProps, srcProps)
+		if srcRequired, ok := srcSchema["required"].([]interface{}); ok {
+			dstRequired, _ := dstSchema["required"].([]interface{})
+			dstSchema["required"] = append(dstRequired, srcRequired...)
+		}
+	}
+}
+
+func overwriteValuesSchemaFile(chartDir string, properties map[string]interface{}, merge bool) error {
+	res, err := json.MarshalIndent(map[string]interface{}{
+		"$schema":    jsonSchemaDraft,
+		"type":       "object",
+		"properties": properties,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: unable to marshal values.schema.json", err)
+	}
+	return writeFile(filepath.Join(chartDir, "values.schema.json"), append(res, '\n'), merge)
+}
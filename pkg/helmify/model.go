@@ -6,6 +6,8 @@ import (
 	"github.com/arttor/helmify/pkg/config"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Processor - converts k8s object to helm template.
@@ -26,9 +28,28 @@ type Template interface {
 	Write(writer io.Writer) error
 }
 
+// ValuesOverlay - Template carrying environment specific values. Overlay values are written into values-<Env>.yaml
+// file next to values.yaml and are not merged into values.yaml. Overlay has no template file.
+type ValuesOverlay interface {
+	Template
+	// Env - returns environment name of the overlay.
+	Env() string
+}
+
+// ValuesSchema - Template with JSON schema of its values. Schemas of all templates are merged into values.schema.json
+// chart file.
+type ValuesSchema interface {
+	Template
+	// ValuesSchema - returns JSON schema properties of template values by top level values key.
+	ValuesSchema() map[string]interface{}
+}
+
+// ValuesTransform - adjusts values merged from all chart templates before values.yaml is written.
+type ValuesTransform func(Values) Values
+
 // Output - converts Template into helm chart on disk.
 type Output interface {
-	Create(chartName, chartDir string, Crd bool, certManagerAsSubchart bool, certManagerVersion string, templates []Template, filenames []string) error
+	Create(conf config.Config, templates []Template, filenames []string) error
 }
 
 // AppMetadata handle common information about K8s objects in the chart.
@@ -47,6 +68,24 @@ type AppMetadata interface {
 	// TrimName trims common prefix from object name if exists.
 	// We trim common prefix because helm already using release for this purpose.
 	TrimName(objName string) string
+	// HasObject returns true if chart contains object of given kind and name.
+	HasObject(kind, name string) bool
+	// IsSharedPortName returns true if named port is declared or referenced by more than one chart object.
+	// Example: Service targetPort referencing Deployment container port, ServiceMonitor endpoint referencing Service port.
+	IsSharedPortName(name string) bool
+	// Workload returns GVK of scalable chart workload (Deployment, StatefulSet, ReplicaSet) with given name.
+	// Returns false if there is no such workload in the chart.
+	Workload(name string) (schema.GroupVersionKind, bool)
+	// ServicePort returns name and number of chart Service port matching given port number or name.
+	// Returns false if there is no such Service in the chart or the Service does not expose the port.
+	ServicePort(svcName string, port intstr.IntOrString) (string, int32, bool)
+	// PodSelector returns GVK, name and selector labels of chart workload fronted by given Service selector.
+	// Workload is resolved by its pod template labels containing all selector labels.
+	// Returns false if selector matches no chart workload or more than one.
+	PodSelector(selector map[string]string) (schema.GroupVersionKind, string, map[string]string, bool)
+	// CRDSchema returns OpenAPI v3 schema of custom resource with given GVK from chart CustomResourceDefinition.
+	// Returns false if the chart has no such CustomResourceDefinition.
+	CRDSchema(gvk schema.GroupVersionKind) (map[string]interface{}, bool)
 
 	Config() config.Config
 }
@@ -3,10 +3,12 @@ package helmify
 import (
 	"dario.cat/mergo"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
+	"helm.sh/helm/v3/pkg/strvals"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -22,6 +24,22 @@ func (v *Values) Merge(values Values) error {
 	return nil
 }
 
+// Set - sets values from helm '--set' expression, e.g. 'a.b=1,c=true'. Numbers and booleans are typed accordingly.
+func (v *Values) Set(expr string) error {
+	if err := strvals.ParseInto(expr, *v); err != nil {
+		return fmt.Errorf("%w: unable to set value %q", err, expr)
+	}
+	return nil
+}
+
+// SetString - sets values from helm '--set-string' expression. Values are always set as strings.
+func (v *Values) SetString(expr string) error {
+	if err := strvals.ParseIntoString(expr, *v); err != nil {
+		return fmt.Errorf("%w: unable to set string value %q", err, expr)
+	}
+	return nil
+}
+
 // Add - adds given value to values and returns its helm template representation {{ .Values.<valueName> }}
 func (v *Values) Add(value interface{}, name ...string) (string, error) {
 	name = toCamelCase(name)
@@ -72,6 +90,20 @@ func (v *Values) AddYaml(value interface{}, indent int, newLine bool, name ...st
 // AddSecret - adds empty value to values and returns its helm template representation {{ required "<valueName>" .Values.<valueName> }}.
 // Set toBase64=true for Secret data to be base64 encoded and set false for Secret stringData.
 func (v *Values) AddSecret(toBase64 bool, name ...string) (string, error) {
+	if toBase64 {
+		return v.addRequired(name, "b64enc", "quote")
+	}
+	return v.addRequired(name, "quote")
+}
+
+// AddRequired - adds empty value to values and returns its helm template representation
+// {{ required "<valueName> is required" .Values.<valueName> | quote }}. Use it for values with no safe default:
+// chart rendering fails until the value is set instead of silently using an empty one.
+func (v *Values) AddRequired(name ...string) (string, error) {
+	return v.addRequired(name, "quote")
+}
+
+func (v *Values) addRequired(name []string, pipeline ...string) (string, error) {
 	name = toCamelCase(name)
 	nameStr := strings.Join(name, ".")
 	err := unstructured.SetNestedField(*v, "", name...)
@@ -79,10 +111,35 @@ func (v *Values) AddSecret(toBase64 bool, name ...string) (string, error) {
 		return "", fmt.Errorf("%w: unable to set value: %v", err, nameStr)
 	}
 	res := fmt.Sprintf(`{{ required "%[1]s is required" .Values.%[1]s`, nameStr)
-	if toBase64 {
-		res += " | b64enc"
+	for _, f := range pipeline {
+		res += " | " + f
+	}
+	return res + " }}", nil
+}
+
+// Path - returns dot separated values path of given value name as used by Add, e.g. 'myApp.dbHost'.
+func Path(name ...string) string {
+	return strings.Join(toCamelCase(append([]string{}, name...)), ".")
+}
+
+// Paths - returns sorted dot separated paths of all leaf values, e.g. 'myApp.replicas'.
+// Maps and lists are leaves when empty, list items are not traversed.
+func (v Values) Paths() []string {
+	var res []string
+	var walk func(prefix string, val map[string]interface{})
+	walk = func(prefix string, val map[string]interface{}) {
+		for k, item := range val {
+			path := prefix + k
+			if m, ok := item.(map[string]interface{}); ok && len(m) != 0 {
+				walk(path+".", m)
+				continue
+			}
+			res = append(res, path)
+		}
 	}
-	return res + " | quote }}", err
+	walk("", v)
+	sort.Strings(res)
+	return res
 }
 
 func toCamelCase(name []string) []string {
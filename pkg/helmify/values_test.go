@@ -82,3 +82,48 @@ func TestValues_AddSecret(t *testing.T) {
 		assert.NotContains(t, res, "b64enc")
 	})
 }
+
+func TestValues_AddRequired(t *testing.T) {
+	testVal := Values{}
+	res, err := testVal.AddRequired("db", "PASSWORD")
+	assert.NoError(t, err)
+	assert.Equal(t, `{{ required "db.password is required" .Values.db.password | quote }}`, res)
+	assert.Equal(t, Values{"db": map[string]interface{}{"password": ""}}, testVal)
+}
+
+func TestPath(t *testing.T) {
+	name := []string{"my-app", "DB_HOST"}
+	assert.Equal(t, "myApp.dbHost", Path(name...))
+	assert.Equal(t, []string{"my-app", "DB_HOST"}, name)
+}
+
+func TestValues_Set(t *testing.T) {
+	t.Run("set typed value", func(t *testing.T) {
+		testVal := Values{"a": map[string]interface{}{"b": "c"}}
+		assert.NoError(t, testVal.Set("a.replicas=2,a.enabled=true"))
+		assert.Equal(t, Values{"a": map[string]interface{}{"b": "c", "replicas": int64(2), "enabled": true}}, testVal)
+	})
+	t.Run("set string value", func(t *testing.T) {
+		testVal := Values{"app": map[string]interface{}{"tag": "latest"}}
+		assert.NoError(t, testVal.SetString("app.tag=1.20,app.port=8080"))
+		assert.Equal(t, Values{"app": map[string]interface{}{"tag": "1.20", "port": "8080"}}, testVal)
+	})
+	t.Run("invalid expression", func(t *testing.T) {
+		testVal := Values{}
+		assert.Error(t, testVal.Set("a.b"))
+	})
+}
+
+func TestValues_Paths(t *testing.T) {
+	testVal := Values{
+		"web": map[string]interface{}{
+			"replicas": int64(2),
+			"app":      map[string]interface{}{"image": map[string]interface{}{"tag": "1.25"}},
+			"args":     []interface{}{"--debug"},
+			"labels":   map[string]interface{}{},
+		},
+		"global": map[string]interface{}{"domain": "example.com"},
+	}
+	assert.Equal(t, []string{"global.domain", "web.app.image.tag", "web.args", "web.labels", "web.replicas"}, testVal.Paths())
+	assert.Empty(t, Values{}.Paths())
+}
@@ -0,0 +1,42 @@
+package metadata
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// loadCRDSchemas - registers OpenAPI schema of every served version of CustomResourceDefinition object.
+func (a *Service) loadCRDSchemas(obj *unstructured.Unstructured) {
+	if obj.GroupVersionKind() != crdGVK {
+		return
+	}
+	group, _, _ := unstructured.NestedString(obj.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(obj.Object, "spec", "names", "kind")
+	versions, _, _ := unstructured.NestedSlice(obj.Object, "spec", "versions")
+	for _, v := range versions {
+		version, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(version, "name")
+		openAPISchema, ok, _ := unstructured.NestedMap(version, "schema", "openAPIV3Schema")
+		if !ok {
+			continue
+		}
+		if a.crdSchemas == nil {
+			a.crdSchemas = map[schema.GroupVersionKind]map[string]interface{}{}
+		}
+		a.crdSchemas[schema.GroupVersionKind{Group: group, Version: name, Kind: kind}] = openAPISchema
+	}
+}
+
+// CRDSchema - returns OpenAPI v3 schema of custom resource with given GVK from chart CustomResourceDefinition.
+// Returns false if there is no such CustomResourceDefinition in the chart or its version has no schema.
+func (a *Service) CRDSchema(gvk schema.GroupVersionKind) (map[string]interface{}, bool) {
+	s, ok := a.crdSchemas[gvk]
+	if !ok {
+		return nil, false
+	}
+	return runtime.DeepCopyJSON(s), true
+}
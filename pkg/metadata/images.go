@@ -0,0 +1,50 @@
+package metadata
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ContainerImage - image of a container from chart pod specs.
+type ContainerImage struct {
+	// Name - name of the first container using the image.
+	Name string `json:"name"`
+	// Image - image reference as it is in the input: registry, repository and tag.
+	Image string `json:"image"`
+}
+
+// loadImages - registers images of pod containers and init containers of given object.
+func (a *Service) loadImages(obj *unstructured.Unstructured) {
+	podSpec := findPodSpec(obj)
+	if podSpec == nil {
+		return
+	}
+	for _, key := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(podSpec, key)
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _, _ := unstructured.NestedString(container, "name")
+			image, _, _ := unstructured.NestedString(container, "image")
+			if image == "" || a.hasImage(image) {
+				continue
+			}
+			a.images = append(a.images, ContainerImage{Name: name, Image: image})
+		}
+	}
+}
+
+func (a *Service) hasImage(image string) bool {
+	for _, i := range a.images {
+		if i.Image == image {
+			return true
+		}
+	}
+	return false
+}
+
+// Images returns distinct container images of chart pod specs in load order.
+func (a *Service) Images() []ContainerImage {
+	return a.images
+}
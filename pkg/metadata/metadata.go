@@ -6,7 +6,9 @@ import (
 	"strings"
 
 	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/warning"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -25,6 +27,48 @@ var crdGVK = schema.GroupVersionKind{
 	Kind:    "CustomResourceDefinition",
 }
 
+var svcGVK = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "Service",
+}
+
+var serviceMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+var podMonitorGVK = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PodMonitor",
+}
+
+var ingressGVK = schema.GroupVersionKind{
+	Group:   "networking.k8s.io",
+	Version: "v1",
+	Kind:    "Ingress",
+}
+
+var certificateGVK = schema.GroupVersionKind{
+	Group:   "cert-manager.io",
+	Version: "v1",
+	Kind:    "Certificate",
+}
+
+var replicationControllerGVK = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "ReplicationController",
+}
+
+var deploymentGVK = schema.GroupVersionKind{
+	Group:   "apps",
+	Version: "v1",
+	Kind:    "Deployment",
+}
+
 func New(conf config.Config) *Service {
 	return &Service{names: make(map[string]struct{}), conf: conf}
 }
@@ -33,7 +77,23 @@ type Service struct {
 	commonPrefix string
 	namespace    string
 	names        map[string]struct{}
-	conf         config.Config
+	// kindNames - object names by kind.
+	kindNames map[string]map[string]struct{}
+	// ports - named ports index: port name -> objects declaring or referencing it.
+	ports map[string]map[string]struct{}
+	// workloads - scalable workloads index: object name -> object GVK.
+	workloads map[string]schema.GroupVersionKind
+	// servicePorts - Service ports index: service name -> ports.
+	servicePorts map[string][]corev1.ServicePort
+	// podWorkloads - pod controllers selectors and pod template labels in load order.
+	podWorkloads []podWorkload
+	// references - objects referenced by workloads in load order.
+	references []WorkloadReferences
+	// crdSchemas - OpenAPI schemas of custom resources by GVK from chart CustomResourceDefinitions.
+	crdSchemas map[schema.GroupVersionKind]map[string]interface{}
+	// images - distinct container images in load order.
+	images []ContainerImage
+	conf   config.Config
 }
 
 func (a *Service) Config() config.Config {
@@ -57,18 +117,87 @@ var _ helmify.AppMetadata = &Service{}
 // Load processed objects one-by-one before actual processing to define app namespace, name common prefix and
 // other app meta information.
 func (a *Service) Load(obj *unstructured.Unstructured) {
-	a.names[obj.GetName()] = struct{}{}
+	a.addName(a.templatedGVK(obj).Kind, obj.GetName())
+	a.loadCertificateSecret(obj)
 	a.commonPrefix = detectCommonPrefix(obj, a.commonPrefix)
+	a.loadPorts(obj)
+	a.loadServicePorts(obj)
+	a.loadWorkload(obj)
+	a.loadPodWorkload(obj)
+	a.loadReferences(obj)
+	a.loadCRDSchemas(obj)
+	a.loadImages(obj)
 	objNs := extractAppNamespace(obj)
 	if objNs == "" {
 		return
 	}
 	if a.namespace != "" && a.namespace != objNs {
-		logrus.Warnf("Two different namespaces for app detected: %s and %s. Resulted char will have single namespace.", objNs, a.namespace)
+		warning.Report(logrus.WithFields(logrus.Fields{
+			"Namespace":    objNs,
+			"AppNamespace": a.namespace,
+		}), "Two different namespaces for app detected. Resulted chart will have single namespace.")
 	}
 	a.namespace = objNs
 }
 
+func (a *Service) addName(kind, name string) {
+	a.names[name] = struct{}{}
+	if a.kindNames == nil {
+		a.kindNames = map[string]map[string]struct{}{}
+	}
+	if a.kindNames[kind] == nil {
+		a.kindNames[kind] = map[string]struct{}{}
+	}
+	a.kindNames[kind][name] = struct{}{}
+}
+
+// loadCertificateSecret - registers Secret issued by cert-manager Certificate as a chart Secret,
+// so references to it are templated the same way as the Certificate secretName.
+func (a *Service) loadCertificateSecret(obj *unstructured.Unstructured) {
+	if obj.GroupVersionKind() != certificateGVK {
+		return
+	}
+	secretName, _, _ := unstructured.NestedString(obj.Object, "spec", "secretName")
+	if secretName != "" {
+		a.addName("Secret", secretName)
+	}
+}
+
+// HasObject returns true if chart contains object of given kind and name.
+func (a *Service) HasObject(kind, name string) bool {
+	_, ok := a.kindNames[kind][name]
+	return ok
+}
+
+// templatedGVK - returns GVK of the chart object templated from given object. ReplicationController is templated
+// as Deployment when ConvertReplicationControllers is enabled.
+func (a *Service) templatedGVK(obj *unstructured.Unstructured) schema.GroupVersionKind {
+	if obj.GroupVersionKind() == replicationControllerGVK && a.conf.ConvertReplicationControllers {
+		return deploymentGVK
+	}
+	return obj.GroupVersionKind()
+}
+
+// loadWorkload - registers object if it can be a target of HorizontalPodAutoscaler.
+func (a *Service) loadWorkload(obj *unstructured.Unstructured) {
+	switch gvk := obj.GroupVersionKind(); {
+	case gvk.Group == "apps" && (gvk.Kind == "Deployment" || gvk.Kind == "StatefulSet" || gvk.Kind == "ReplicaSet"):
+	case gvk == replicationControllerGVK:
+	default:
+		return
+	}
+	if a.workloads == nil {
+		a.workloads = map[string]schema.GroupVersionKind{}
+	}
+	a.workloads[obj.GetName()] = a.templatedGVK(obj)
+}
+
+// Workload returns GVK of scalable chart workload with given name. Returns false if chart has no such workload.
+func (a *Service) Workload(name string) (schema.GroupVersionKind, bool) {
+	gvk, ok := a.workloads[name]
+	return gvk, ok
+}
+
 // Namespace returns detected app namespace.
 func (a *Service) Namespace() string {
 	return a.namespace
@@ -8,6 +8,7 @@ import (
 	"github.com/arttor/helmify/internal"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 const res = `apiVersion: v1
@@ -117,6 +118,273 @@ func Test_Service(t *testing.T) {
 	})
 }
 
+func Test_Service_Workload(t *testing.T) {
+	rc := internal.GenerateObj(`apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  template:
+    metadata:
+      labels:
+        app: web`)
+	t.Run("replication controller", func(t *testing.T) {
+		testSvc := New(config.Config{})
+		testSvc.Load(rc)
+		gvk, ok := testSvc.Workload("web")
+		assert.True(t, ok)
+		assert.Equal(t, replicationControllerGVK, gvk)
+		assert.True(t, testSvc.HasObject("ReplicationController", "web"))
+	})
+	t.Run("converted replication controller", func(t *testing.T) {
+		testSvc := New(config.Config{ConvertReplicationControllers: true})
+		testSvc.Load(rc)
+		gvk, ok := testSvc.Workload("web")
+		assert.True(t, ok)
+		assert.Equal(t, deploymentGVK, gvk)
+		assert.True(t, testSvc.HasObject("Deployment", "web"))
+		podGVK, name, _, ok := testSvc.PodSelector(map[string]string{"app": "web"})
+		assert.True(t, ok)
+		assert.Equal(t, deploymentGVK, podGVK)
+		assert.Equal(t, "web", name)
+	})
+}
+
+func Test_Service_IsSharedPortName(t *testing.T) {
+	svc := internal.GenerateObj(`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-service
+spec:
+  ports:
+  - name: http
+    port: 80
+    targetPort: web`)
+	depl := internal.GenerateObj(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        ports:
+        - containerPort: 8080
+          name: web
+        - containerPort: 9090
+          name: metrics
+        readinessProbe:
+          httpGet:
+            port: metrics`)
+	testSvc := New(config.Config{})
+	testSvc.Load(svc)
+	testSvc.Load(depl)
+	assert.True(t, testSvc.IsSharedPortName("web"), "service targetPort references container port")
+	assert.False(t, testSvc.IsSharedPortName("http"), "port declared only by service")
+	assert.False(t, testSvc.IsSharedPortName("metrics"), "port referenced only within single object")
+	assert.False(t, (&Service{}).IsSharedPortName("web"))
+
+	testSvc.Load(internal.GenerateObj(`apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: my-app
+spec:
+  rules:
+  - http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: my-app-service
+            port:
+              name: http`))
+	assert.True(t, testSvc.IsSharedPortName("http"), "ingress backend references service port")
+}
+
+func Test_Service_ServicePort(t *testing.T) {
+	testSvc := New(config.Config{})
+	testSvc.Load(internal.GenerateObj(`apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-service
+spec:
+  ports:
+  - name: http
+    port: 80
+  - port: 9090`))
+	name, number, found := testSvc.ServicePort("my-app-service", intstr.FromInt(80))
+	assert.True(t, found)
+	assert.Equal(t, "http", name)
+	assert.Equal(t, int32(80), number)
+
+	name, number, found = testSvc.ServicePort("my-app-service", intstr.FromString("http"))
+	assert.True(t, found)
+	assert.Equal(t, "http", name)
+	assert.Equal(t, int32(80), number)
+
+	name, _, found = testSvc.ServicePort("my-app-service", intstr.FromInt(9090))
+	assert.True(t, found)
+	assert.Empty(t, name)
+
+	_, _, found = testSvc.ServicePort("my-app-service", intstr.FromInt(8080))
+	assert.False(t, found)
+	_, _, found = testSvc.ServicePort("other", intstr.FromInt(80))
+	assert.False(t, found)
+}
+
+func Test_Service_PodSelector(t *testing.T) {
+	testSvc := New(config.Config{})
+	testSvc.Load(internal.GenerateObj(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend
+        part-of: my-app`))
+	testSvc.Load(internal.GenerateObj(`apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+        part-of: my-app`))
+
+	gvk, name, selector, found := testSvc.PodSelector(map[string]string{"app": "web", "tier": "frontend"})
+	assert.True(t, found)
+	assert.Equal(t, "Deployment", gvk.Kind)
+	assert.Equal(t, "my-app-web", name)
+	assert.Equal(t, map[string]string{"app": "web"}, selector)
+
+	_, name, _, found = testSvc.PodSelector(map[string]string{"app": "db"})
+	assert.True(t, found)
+	assert.Equal(t, "my-app-db", name)
+
+	_, _, _, found = testSvc.PodSelector(map[string]string{"part-of": "my-app"})
+	assert.False(t, found, "selector matches more than one workload")
+	_, _, _, found = testSvc.PodSelector(map[string]string{"app": "web", "tier": "backend"})
+	assert.False(t, found)
+	_, _, _, found = testSvc.PodSelector(nil)
+	assert.False(t, found)
+}
+
+func Test_Service_PodSelector_CorrelationLabels(t *testing.T) {
+	const api = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-api
+spec:
+  selector:
+    matchLabels:
+      component: api
+      tier: server
+  template:
+    metadata:
+      labels:
+        component: api
+        tier: server
+        part-of: shop`
+	const worker = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-api-worker
+spec:
+  selector:
+    matchLabels:
+      component: api
+      role: worker
+  template:
+    metadata:
+      labels:
+        component: api
+        role: worker
+        part-of: shop`
+	load := func(conf config.Config) *Service {
+		testSvc := New(conf)
+		testSvc.Load(internal.GenerateObj(api))
+		testSvc.Load(internal.GenerateObj(worker))
+		return testSvc
+	}
+
+	_, _, _, found := load(config.Config{}).PodSelector(map[string]string{"component": "api"})
+	assert.False(t, found, "default correlation labels do not identify workloads")
+
+	testSvc := load(config.Config{CorrelationLabels: []string{"component", "role"}})
+	_, name, selector, found := testSvc.PodSelector(map[string]string{"component": "api"})
+	assert.True(t, found)
+	assert.Equal(t, "my-app-api", name)
+	assert.Equal(t, map[string]string{"component": "api", "tier": "server"}, selector)
+
+	_, name, _, found = testSvc.PodSelector(map[string]string{"component": "api", "role": "worker"})
+	assert.True(t, found)
+	assert.Equal(t, "my-app-api-worker", name)
+
+	_, _, _, found = testSvc.PodSelector(map[string]string{"part-of": "shop"})
+	assert.False(t, found)
+}
+
+func Test_Service_References(t *testing.T) {
+	testSvc := New(config.Config{})
+	testSvc.Load(internal.GenerateObj(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config`))
+	testSvc.Load(internal.GenerateObj(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  template:
+    spec:
+      serviceAccountName: my-app-sa
+      containers:
+      - name: web
+        image: nginx:1.25
+        envFrom:
+        - configMapRef:
+            name: my-app-config
+        env:
+        - name: PASSWORD
+          valueFrom:
+            secretKeyRef:
+              name: db-credentials
+              key: password
+      volumes:
+      - name: config
+        configMap:
+          name: my-app-config
+      - name: data
+        persistentVolumeClaim:
+          claimName: my-app-data`))
+	assert.Equal(t, []WorkloadReferences{{
+		Kind: "Deployment",
+		Name: "my-app-web",
+		References: []Reference{
+			{Kind: "ConfigMap", Name: "my-app-config", Source: SourceVolume, InChart: true},
+			{Kind: "PersistentVolumeClaim", Name: "my-app-data", Source: SourceVolume},
+			{Kind: "Secret", Name: "db-credentials", Source: SourceEnv},
+			{Kind: "ConfigMap", Name: "my-app-config", Source: SourceEnvFrom, InChart: true},
+			{Kind: "ServiceAccount", Name: "my-app-sa", Source: SourceServiceAccount},
+		},
+	}}, testSvc.References())
+}
+
 func createRes(name, ns string) *unstructured.Unstructured {
 	objYaml := fmt.Sprintf(res, name, ns)
 	return internal.GenerateObj(objYaml)
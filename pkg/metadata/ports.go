@@ -0,0 +1,162 @@
+package metadata
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var probes = []string{"livenessProbe", "readinessProbe", "startupProbe"}
+
+// loadPorts - registers named ports declared or referenced by given object.
+func (a *Service) loadPorts(obj *unstructured.Unstructured) {
+	for _, name := range namedPorts(obj) {
+		if a.ports == nil {
+			a.ports = map[string]map[string]struct{}{}
+		}
+		refs, ok := a.ports[name]
+		if !ok {
+			refs = map[string]struct{}{}
+			a.ports[name] = refs
+		}
+		refs[obj.GetKind()+"/"+obj.GetName()] = struct{}{}
+	}
+}
+
+// loadServicePorts - registers ports exposed by Service object.
+func (a *Service) loadServicePorts(obj *unstructured.Unstructured) {
+	if obj.GroupVersionKind() != svcGVK {
+		return
+	}
+	var svc corev1.Service
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &svc); err != nil {
+		return
+	}
+	if a.servicePorts == nil {
+		a.servicePorts = map[string][]corev1.ServicePort{}
+	}
+	a.servicePorts[obj.GetName()] = svc.Spec.Ports
+}
+
+// ServicePort - returns name and number of chart Service port matching given port number or name.
+func (a *Service) ServicePort(svcName string, port intstr.IntOrString) (string, int32, bool) {
+	for _, p := range a.servicePorts[svcName] {
+		if port.Type == intstr.Int && p.Port == port.IntVal ||
+			port.Type == intstr.String && p.Name != "" && p.Name == port.StrVal {
+			return p.Name, p.Port, true
+		}
+	}
+	return "", 0, false
+}
+
+// IsSharedPortName - returns true if named port is declared or referenced by more than one object of the chart.
+// For example: Service targetPort referencing Deployment container port or ServiceMonitor endpoint referencing Service port.
+func (a *Service) IsSharedPortName(name string) bool {
+	return len(a.ports[name]) > 1
+}
+
+func namedPorts(obj *unstructured.Unstructured) []string {
+	var res []string
+	switch obj.GroupVersionKind() {
+	case svcGVK:
+		ports, _, _ := unstructured.NestedSlice(obj.Object, "spec", "ports")
+		res = append(res, portNames(ports, "name", "targetPort")...)
+	case serviceMonitorGVK:
+		endpoints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "endpoints")
+		res = append(res, portNames(endpoints, "port", "targetPort")...)
+	case podMonitorGVK:
+		endpoints, _, _ := unstructured.NestedSlice(obj.Object, "spec", "podMetricsEndpoints")
+		res = append(res, portNames(endpoints, "port")...)
+	case ingressGVK:
+		res = append(res, ingressBackendPortNames(obj)...)
+	}
+	podSpec := findPodSpec(obj)
+	if podSpec == nil {
+		return res
+	}
+	for _, containerType := range []string{"containers", "initContainers"} {
+		containers, _, _ := unstructured.NestedSlice(podSpec, containerType)
+		res = append(res, containerPortNames(containers)...)
+	}
+	return res
+}
+
+func containerPortNames(containers []interface{}) []string {
+	var res []string
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ports, _, _ := unstructured.NestedSlice(container, "ports")
+		res = append(res, portNames(ports, "name")...)
+		for _, probe := range probes {
+			for _, handler := range []string{"httpGet", "tcpSocket"} {
+				port, ok, _ := unstructured.NestedFieldNoCopy(container, probe, handler, "port")
+				if name, isStr := port.(string); ok && isStr && name != "" {
+					res = append(res, name)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// ingressBackendPortNames - returns names of Service ports referenced by default backend and rules backends of Ingress.
+func ingressBackendPortNames(obj *unstructured.Unstructured) []string {
+	var res []string
+	if name, _, _ := unstructured.NestedString(obj.Object, "spec", "defaultBackend", "service", "port", "name"); name != "" {
+		res = append(res, name)
+	}
+	rules, _, _ := unstructured.NestedSlice(obj.Object, "spec", "rules")
+	for _, r := range rules {
+		rule, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		paths, _, _ := unstructured.NestedSlice(rule, "http", "paths")
+		for _, p := range paths {
+			path, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _, _ := unstructured.NestedString(path, "backend", "service", "port", "name"); name != "" {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+// portNames - returns string values of given fields from the list of port definitions.
+func portNames(ports []interface{}, fields ...string) []string {
+	var res []string
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			if name, isStr := port[field].(string); isStr && name != "" {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+// findPodSpec - returns pod spec of pod or pod controller object. Returns nil if object has no pod spec.
+func findPodSpec(obj *unstructured.Unstructured) map[string]interface{} {
+	if obj.GetKind() == "Pod" {
+		spec, _, _ := unstructured.NestedMap(obj.Object, "spec")
+		return spec
+	}
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec"); ok {
+		return spec
+	}
+	if spec, ok, _ := unstructured.NestedMap(obj.Object, "spec", "jobTemplate", "spec", "template", "spec"); ok {
+		return spec
+	}
+	return nil
+}
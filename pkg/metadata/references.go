@@ -0,0 +1,120 @@
+package metadata
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Reference sources of workload references.
+const (
+	SourceVolume           = "volume"
+	SourceEnv              = "env"
+	SourceEnvFrom          = "envFrom"
+	SourceImagePullSecrets = "imagePullSecrets"
+	SourceServiceAccount   = "serviceAccountName"
+)
+
+// Reference - object referenced by a workload pod spec.
+type Reference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Source - pod spec field referencing the object.
+	Source string `json:"source"`
+	// InChart - true if referenced object is a part of the chart.
+	InChart bool `json:"inChart"`
+}
+
+// WorkloadReferences - ConfigMaps, Secrets, PersistentVolumeClaims and ServiceAccounts referenced by workload.
+type WorkloadReferences struct {
+	Kind       string      `json:"kind"`
+	Name       string      `json:"name"`
+	References []Reference `json:"references"`
+}
+
+// loadReferences - registers objects referenced by pod spec of given object.
+func (a *Service) loadReferences(obj *unstructured.Unstructured) {
+	podSpecMap := findPodSpec(obj)
+	if podSpecMap == nil {
+		return
+	}
+	var podSpec corev1.PodSpec
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podSpecMap, &podSpec); err != nil {
+		return
+	}
+	for _, w := range a.references {
+		// duplicates and environment variants of the same workload
+		if w.Kind == obj.GetKind() && w.Name == obj.GetName() {
+			return
+		}
+	}
+	res := WorkloadReferences{Kind: obj.GetKind(), Name: obj.GetName()}
+	seen := map[Reference]bool{}
+	add := func(kind, name, source string) {
+		ref := Reference{Kind: kind, Name: name, Source: source}
+		if name == "" || seen[ref] {
+			return
+		}
+		seen[ref] = true
+		res.References = append(res.References, ref)
+	}
+	for _, v := range podSpec.Volumes {
+		switch {
+		case v.ConfigMap != nil:
+			add("ConfigMap", v.ConfigMap.Name, SourceVolume)
+		case v.Secret != nil:
+			add("Secret", v.Secret.SecretName, SourceVolume)
+		case v.PersistentVolumeClaim != nil:
+			add("PersistentVolumeClaim", v.PersistentVolumeClaim.ClaimName, SourceVolume)
+		case v.Projected != nil:
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					add("ConfigMap", s.ConfigMap.Name, SourceVolume)
+				}
+				if s.Secret != nil {
+					add("Secret", s.Secret.Name, SourceVolume)
+				}
+			}
+		}
+	}
+	for _, c := range append(podSpec.InitContainers, podSpec.Containers...) {
+		for _, e := range c.Env {
+			if e.ValueFrom == nil {
+				continue
+			}
+			if e.ValueFrom.ConfigMapKeyRef != nil {
+				add("ConfigMap", e.ValueFrom.ConfigMapKeyRef.Name, SourceEnv)
+			}
+			if e.ValueFrom.SecretKeyRef != nil {
+				add("Secret", e.ValueFrom.SecretKeyRef.Name, SourceEnv)
+			}
+		}
+		for _, e := range c.EnvFrom {
+			if e.ConfigMapRef != nil {
+				add("ConfigMap", e.ConfigMapRef.Name, SourceEnvFrom)
+			}
+			if e.SecretRef != nil {
+				add("Secret", e.SecretRef.Name, SourceEnvFrom)
+			}
+		}
+	}
+	for _, s := range podSpec.ImagePullSecrets {
+		add("Secret", s.Name, SourceImagePullSecrets)
+	}
+	add("ServiceAccount", podSpec.ServiceAccountName, SourceServiceAccount)
+	a.references = append(a.references, res)
+}
+
+// References - returns objects referenced by chart workloads in load order. Referenced objects are marked as in chart
+// if they were loaded into chart as well.
+func (a *Service) References() []WorkloadReferences {
+	res := make([]WorkloadReferences, len(a.references))
+	for i, w := range a.references {
+		res[i] = WorkloadReferences{Kind: w.Kind, Name: w.Name, References: make([]Reference, len(w.References))}
+		for j, ref := range w.References {
+			ref.InChart = a.HasObject(ref.Kind, ref.Name)
+			res[i].References[j] = ref
+		}
+	}
+	return res
+}
@@ -0,0 +1,101 @@
+package metadata
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// podWorkload - pod controller selector and pod template labels.
+type podWorkload struct {
+	gvk      schema.GroupVersionKind
+	name     string
+	selector map[string]string
+	labels   map[string]string
+}
+
+// loadPodWorkload - registers selector and pod template labels of pod controller object.
+func (a *Service) loadPodWorkload(obj *unstructured.Unstructured) {
+	var selector map[string]string
+	gvk := obj.GroupVersionKind()
+	switch {
+	case gvk.Group == "apps" && (gvk.Kind == "Deployment" || gvk.Kind == "StatefulSet" ||
+		gvk.Kind == "DaemonSet" || gvk.Kind == "ReplicaSet"):
+		selector, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "selector", "matchLabels")
+	case gvk.Group == "" && gvk.Kind == "ReplicationController":
+		selector, _, _ = unstructured.NestedStringMap(obj.Object, "spec", "selector")
+	default:
+		return
+	}
+	labels, _, _ := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+	if len(selector) == 0 {
+		// ReplicationController selector defaults to pod template labels
+		selector = labels
+	}
+	a.podWorkloads = append(a.podWorkloads, podWorkload{gvk: a.templatedGVK(obj), name: obj.GetName(), selector: selector, labels: labels})
+}
+
+// defaultCorrelationLabels - label keys identifying workload pods used when CorrelationLabels is not configured.
+var defaultCorrelationLabels = []string{"app", "app.kubernetes.io/name"}
+
+// PodSelector - returns GVK, name and selector labels of the only chart workload with pod template labels matching
+// all given selector labels. If several workloads match, the one identified by the selector is chosen: correlation
+// labels of the workload selector are all present in the given selector. Returns false if no workload or more than
+// one workload match, or if the workload selector also matches pods of other chart workloads, so replacing the given
+// selector with the workload selector would select more pods.
+func (a *Service) PodSelector(selector map[string]string) (schema.GroupVersionKind, string, map[string]string, bool) {
+	if len(selector) == 0 {
+		return schema.GroupVersionKind{}, "", nil, false
+	}
+	var found []podWorkload
+	for _, w := range a.podWorkloads {
+		if matchLabels(selector, w.labels) {
+			found = append(found, w)
+		}
+	}
+	if len(found) > 1 {
+		found = a.correlated(selector, found)
+	}
+	if len(found) != 1 {
+		return schema.GroupVersionKind{}, "", nil, false
+	}
+	for _, w := range a.podWorkloads {
+		if w.gvk != found[0].gvk || w.name != found[0].name {
+			if matchLabels(found[0].selector, w.labels) {
+				return schema.GroupVersionKind{}, "", nil, false
+			}
+		}
+	}
+	return found[0].gvk, found[0].name, found[0].selector, true
+}
+
+// correlated - returns workloads with correlation labels of their selectors present in the given selector.
+// Workloads without correlation labels in selector are skipped.
+func (a *Service) correlated(selector map[string]string, workloads []podWorkload) []podWorkload {
+	keys := a.conf.CorrelationLabels
+	if len(keys) == 0 {
+		keys = defaultCorrelationLabels
+	}
+	var res []podWorkload
+	for _, w := range workloads {
+		id := map[string]string{}
+		for _, k := range keys {
+			if v, ok := w.selector[k]; ok {
+				id[k] = v
+			}
+		}
+		if len(id) != 0 && matchLabels(id, selector) {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// matchLabels - returns true if labels contain all selector labels.
+func matchLabels(selector, labels map[string]string) bool {
+	for k, v := range selector {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
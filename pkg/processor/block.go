@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const blockTempl = `{{- toYaml .Values.%s | nindent %d }}`
+
+// ExternalizeBlock moves nested block located in obj under specPath into values under dot separated valuePath.
+// Returns template rendering the block from values with given indent. Example:
+//
+//	ExternalizeBlock(values, podSpec, "web.affinity", 8, "affinity") -> "{{- toYaml .Values.web.affinity | nindent 8 }}"
+//
+// Returns empty string and keeps values unchanged if the block is absent or empty.
+func ExternalizeBlock(values helmify.Values, obj map[string]interface{}, valuePath string, indent int, specPath ...string) (string, error) {
+	block, exists, err := unstructured.NestedFieldCopy(obj, specPath...)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to get %s", err, strings.Join(specPath, "."))
+	}
+	if !exists || isEmptyBlock(block) {
+		return "", nil
+	}
+	err = unstructured.SetNestedField(values, block, strings.Split(valuePath, ".")...)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set %s value", err, valuePath)
+	}
+	return fmt.Sprintf(blockTempl, valuePath, indent), nil
+}
+
+func isEmptyBlock(block interface{}) bool {
+	switch b := block.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(b) == 0
+	case []interface{}:
+		return len(b) == 0
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExternalizeBlock(t *testing.T) {
+	newSpec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"affinity": map[string]interface{}{
+				"nodeAffinity": map[string]interface{}{"key": "zone"},
+			},
+			"tolerations": []interface{}{
+				map[string]interface{}{"key": "dedicated", "operator": "Exists"},
+			},
+			"nodeSelector": map[string]interface{}{},
+		}
+	}
+	t.Run("map", func(t *testing.T) {
+		values := helmify.Values{}
+		res, err := ExternalizeBlock(values, newSpec(), "web.affinity", 8, "affinity")
+		assert.NoError(t, err)
+		assert.Equal(t, "{{- toYaml .Values.web.affinity | nindent 8 }}", res)
+		assert.Equal(t, helmify.Values{
+			"web": map[string]interface{}{
+				"affinity": map[string]interface{}{
+					"nodeAffinity": map[string]interface{}{"key": "zone"},
+				},
+			},
+		}, values)
+	})
+	t.Run("nested path", func(t *testing.T) {
+		values := helmify.Values{}
+		res, err := ExternalizeBlock(values, newSpec(), "web.nodeAffinity", 10, "affinity", "nodeAffinity")
+		assert.NoError(t, err)
+		assert.Equal(t, "{{- toYaml .Values.web.nodeAffinity | nindent 10 }}", res)
+		assert.Equal(t, map[string]interface{}{"key": "zone"}, values["web"].(map[string]interface{})["nodeAffinity"])
+	})
+	t.Run("list", func(t *testing.T) {
+		values := helmify.Values{}
+		res, err := ExternalizeBlock(values, newSpec(), "web.tolerations", 8, "tolerations")
+		assert.NoError(t, err)
+		assert.Equal(t, "{{- toYaml .Values.web.tolerations | nindent 8 }}", res)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"key": "dedicated", "operator": "Exists"},
+		}, values["web"].(map[string]interface{})["tolerations"])
+	})
+	t.Run("absent", func(t *testing.T) {
+		values := helmify.Values{}
+		res, err := ExternalizeBlock(values, newSpec(), "web.volumes", 8, "volumes")
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+		assert.Empty(t, values)
+	})
+	t.Run("empty", func(t *testing.T) {
+		values := helmify.Values{}
+		res, err := ExternalizeBlock(values, newSpec(), "web.nodeSelector", 8, "nodeSelector")
+		assert.NoError(t, err)
+		assert.Empty(t, res)
+		assert.Empty(t, values)
+	})
+	t.Run("not a map", func(t *testing.T) {
+		_, err := ExternalizeBlock(helmify.Values{}, newSpec(), "web.key", 8, "tolerations", "key")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,13 @@
+package processor
+
+import "fmt"
+
+const kubeVersionGuardTempl = `{{- if semverCompare ">=%[1]s-0" .Capabilities.KubeVersion.Version }}
+%[2]s
+{{- end }}`
+
+// KubeVersionGuard - wraps template into condition rendering it only for clusters with version >= minVersion.
+// Used for fields rejected by older k8s versions. Example: KubeVersionGuard("1.27", "timeZone: UTC").
+func KubeVersionGuard(minVersion, template string) string {
+	return fmt.Sprintf(kubeVersionGuardTempl, minVersion, template)
+}
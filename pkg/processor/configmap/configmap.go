@@ -68,7 +68,7 @@ func (d configMap) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 	name := appMeta.TrimName(obj.GetName())
 	var values helmify.Values
 	if field, exists, _ := unstructured.NestedStringMap(obj.Object, "data"); exists {
-		field, values = parseMapData(field, name)
+		field, values = parseMapData(field, name, appMeta.Config().RequiredValues)
 		data, err = yamlformat.Marshal(map[string]interface{}{"data": field}, 0)
 		if err != nil {
 			return true, nil, err
@@ -88,10 +88,20 @@ func (d configMap) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 	}, nil
 }
 
-func parseMapData(data map[string]string, configName string) (map[string]string, helmify.Values) {
+func parseMapData(data map[string]string, configName string, required []string) (map[string]string, helmify.Values) {
 	values := helmify.Values{}
 	for key, value := range data {
 		valuesNamePath := []string{configName, key}
+		if isRequired(required, valuesNamePath) {
+			logrus.WithField("Value", helmify.Path(valuesNamePath...)).Debug("configmap value required: default removed")
+			templatedVal, err := values.AddRequired(valuesNamePath...)
+			if err != nil {
+				logrus.WithError(err).Errorf("unable to process required configmap data: %v", valuesNamePath)
+				continue
+			}
+			data[key] = templatedVal
+			continue
+		}
 		if strings.HasSuffix(key, ".properties") {
 			// handle properties
 			templated, err := parseProperties(value, valuesNamePath, values)
@@ -123,6 +133,17 @@ func parseMapData(data map[string]string, configName string) (map[string]string,
 	return data, values
 }
 
+// isRequired returns true if values path of given name is one of required values paths.
+func isRequired(required []string, name []string) bool {
+	path := helmify.Path(name...)
+	for _, r := range required {
+		if r == path {
+			return true
+		}
+	}
+	return false
+}
+
 // func parseProperties(properties string, path []string, values helmify.Values) (string, error) {
 func parseProperties(properties interface{}, path []string, values helmify.Values) (string, error) {
 	var res strings.Builder
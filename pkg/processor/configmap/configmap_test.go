@@ -1,8 +1,11 @@
 package configmap
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
+
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -33,6 +36,17 @@ func Test_configMap_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, true, processed)
 	})
+	t.Run("required value", func(t *testing.T) {
+		obj := internal.GenerateObj(strConfigmap)
+		meta := metadata.New(config.Config{ChartName: "chart", RequiredValues: []string{"myOperatorManagerConfig.dummyconfigmapkey"}})
+		meta.Load(obj)
+		_, tmpl, err := testInstance.Process(meta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `dummyconfigmapkey: {{ required "myOperatorManagerConfig.dummyconfigmapkey is required"`)
+		assert.Equal(t, "", tmpl.Values()["myOperatorManagerConfig"].(map[string]interface{})["dummyconfigmapkey"])
+	})
 	t.Run("skipped", func(t *testing.T) {
 		obj := internal.TestNs
 		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
@@ -0,0 +1,228 @@
+package customresource
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	fieldPlaceholder = "helmifyCustomResourceField%d"
+	valueTempl       = "{{ .Values.%s }}"
+	stringValueTempl = "{{ .Values.%s | quote }}"
+	blockTempl       = "{{- toYaml .Values.%s | nindent %d }}"
+)
+
+// identifierRe matches field names usable in .Values path of a template.
+var identifierRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// New creates processor for custom resources of CustomResourceDefinitions from the chart.
+// Custom resource spec fields described by CRD OpenAPI schema are moved to values and values.schema.json
+// gets the matching schema. Fields unknown to the schema are kept in the template as they are.
+func New() helmify.Processor {
+	return &customResource{}
+}
+
+type customResource struct{}
+
+// Process custom resource into template. Returns false if schema driven values are disabled in config or
+// the chart has no CustomResourceDefinition for the resource.
+func (c customResource) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if !appMeta.Config().CustomResourceValues {
+		return false, nil, nil
+	}
+	crdSchema, ok := appMeta.CRDSchema(obj.GroupVersionKind())
+	if !ok {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+	kindCamel := strcase.ToLowerCamel(obj.GetKind())
+
+	body := runtime.DeepCopyJSON(obj.Object)
+	delete(body, "apiVersion")
+	delete(body, "kind")
+	delete(body, "metadata")
+	// status is managed by the operator
+	delete(body, "status")
+
+	w := &walker{values: helmify.Values{}}
+	var valuesSchema map[string]interface{}
+	spec, isMap := body["spec"].(map[string]interface{})
+	specSchema, hasSchema, _ := unstructured.NestedMap(crdSchema, "properties", "spec")
+	if isMap && hasSchema {
+		specValuesSchema, err := w.walk(spec, specSchema, []string{nameCamel, kindCamel})
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable to process %s %s", err, obj.GetKind(), obj.GetName())
+		}
+		if specValuesSchema != nil {
+			valuesSchema = map[string]interface{}{
+				nameCamel: map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{kindCamel: specValuesSchema},
+				},
+			}
+		}
+	}
+
+	res, err := yamlformat.Marshal(body, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	// templates are inserted after marshalling to keep them on a single line.
+	for i := len(w.templates) - 1; i >= 0; i-- {
+		res = strings.ReplaceAll(res, fmt.Sprintf(fieldPlaceholder, i), w.templates[i])
+	}
+	return true, &result{
+		name:   name,
+		data:   []byte(meta + "\n" + res),
+		values: w.values,
+		schema: valuesSchema,
+	}, nil
+}
+
+// walker moves custom resource fields to values according to the OpenAPI schema.
+type walker struct {
+	values    helmify.Values
+	templates []string
+}
+
+// walk replaces fields of obj described by objSchema properties with placeholders of templates rendering them from
+// values under valuesPath. Objects with properties are walked recursively, other fields are moved to values as a
+// whole. Returns JSON schema of the moved values or nil if no field was moved.
+func (w *walker) walk(obj, objSchema map[string]interface{}, valuesPath []string) (map[string]interface{}, error) {
+	properties, _, _ := unstructured.NestedMap(objSchema, "properties")
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	valuesProperties := map[string]interface{}{}
+	for _, k := range keys {
+		val := obj[k]
+		path := append(append([]string{}, valuesPath...), k)
+		fieldSchema, ok := properties[k].(map[string]interface{})
+		if !ok || val == nil || !identifierRe.MatchString(k) {
+			logrus.WithField("Field", strings.Join(path, ".")).Debug("custom resource field kept in template: not described by CRD schema")
+			continue
+		}
+		nested, isMap := val.(map[string]interface{})
+		if _, hasProperties := fieldSchema["properties"]; hasProperties && isMap {
+			nestedSchema, err := w.walk(nested, fieldSchema, path)
+			if err != nil {
+				return nil, err
+			}
+			if nestedSchema != nil {
+				valuesProperties[k] = nestedSchema
+			}
+			continue
+		}
+		if err := unstructured.SetNestedField(w.values, val, path...); err != nil {
+			return nil, fmt.Errorf("%w: unable to set %s value", err, strings.Join(path, "."))
+		}
+		obj[k] = fmt.Sprintf(fieldPlaceholder, len(w.templates))
+		w.templates = append(w.templates, fieldTemplate(val, path))
+		valuesProperties[k] = cleanSchema(fieldSchema)
+	}
+	if len(valuesProperties) == 0 {
+		return nil, nil
+	}
+	res := map[string]interface{}{
+		"type":       "object",
+		"properties": valuesProperties,
+	}
+	// only required fields moved to values are kept required
+	required, _, _ := unstructured.NestedStringSlice(objSchema, "required")
+	var valuesRequired []interface{}
+	for _, r := range required {
+		if _, ok := valuesProperties[r]; ok {
+			valuesRequired = append(valuesRequired, r)
+		}
+	}
+	if len(valuesRequired) != 0 {
+		res["required"] = valuesRequired
+	}
+	return res, nil
+}
+
+// fieldTemplate returns template rendering field value from values. Path starts with resource name and kind
+// followed by path of the field in the resource spec.
+func fieldTemplate(val interface{}, path []string) string {
+	valuePath := strings.Join(path, ".")
+	switch val.(type) {
+	case string:
+		return fmt.Sprintf(stringValueTempl, valuePath)
+	case map[string]interface{}, []interface{}:
+		// path has resource name and kind instead of the top level 'spec' key, so block content is nested
+		// len(path)-1 levels deep
+		return fmt.Sprintf(blockTempl, valuePath, 2*len(path)-2)
+	}
+	return fmt.Sprintf(valueTempl, valuePath)
+}
+
+// cleanSchema returns copy of OpenAPI schema without Kubernetes extensions.
+func cleanSchema(s map[string]interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(s))
+	for k, v := range s {
+		if strings.HasPrefix(k, "x-kubernetes-") {
+			continue
+		}
+		res[k] = cleanSchemaValue(v)
+	}
+	return res
+}
+
+func cleanSchemaValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return cleanSchema(val)
+	case []interface{}:
+		res := make([]interface{}, len(val))
+		for i, item := range val {
+			res[i] = cleanSchemaValue(item)
+		}
+		return res
+	}
+	return v
+}
+
+type result struct {
+	name   string
+	data   []byte
+	values helmify.Values
+	schema map[string]interface{}
+}
+
+var _ helmify.ValuesSchema = &result{}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) ValuesSchema() map[string]interface{} {
+	return r.schema
+}
+
+func (r *result) Write(writer io.Writer) error {
+	_, err := writer.Write(r.data)
+	return err
+}
@@ -0,0 +1,137 @@
+package customresource
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	crdYaml = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: databases.example.com
+spec:
+  group: example.com
+  names:
+    kind: Database
+    plural: databases
+    singular: database
+  scope: Namespaced
+  versions:
+  - name: v1
+    served: true
+    storage: true
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            required: ["engine"]
+            properties:
+              engine:
+                type: string
+                enum: ["postgres", "mysql"]
+              replicas:
+                type: integer
+                minimum: 1
+              storage:
+                type: object
+                required: ["size"]
+                properties:
+                  size:
+                    type: string
+                  encrypted:
+                    type: boolean
+              users:
+                type: array
+                items:
+                  type: string
+              parameters:
+                type: object
+                x-kubernetes-preserve-unknown-fields: true`
+
+	crYaml = `apiVersion: example.com/v1
+kind: Database
+metadata:
+  name: my-app-db
+spec:
+  engine: postgres
+  replicas: 2
+  storage:
+    size: 10Gi
+    encrypted: true
+  users:
+  - app
+  parameters:
+    max_connections: "100"
+  unknown: kept
+status:
+  ready: true`
+)
+
+func Test_customResource_Process(t *testing.T) {
+	var testInstance customResource
+
+	newMeta := func(conf config.Config, objYaml ...string) helmify.AppMetadata {
+		appMeta := metadata.New(conf)
+		for _, y := range objYaml {
+			appMeta.Load(internal.GenerateObj(y))
+		}
+		return appMeta
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		processed, _, err := testInstance.Process(newMeta(config.Config{}, crdYaml, crYaml), internal.GenerateObj(crYaml))
+		assert.NoError(t, err)
+		assert.False(t, processed)
+	})
+	t.Run("no crd in chart", func(t *testing.T) {
+		processed, _, err := testInstance.Process(newMeta(config.Config{CustomResourceValues: true}, crYaml), internal.GenerateObj(crYaml))
+		assert.NoError(t, err)
+		assert.False(t, processed)
+	})
+	t.Run("schema driven values", func(t *testing.T) {
+		appMeta := newMeta(config.Config{ChartName: "chart", CustomResourceValues: true}, crdYaml, crYaml)
+		processed, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(crYaml))
+		assert.NoError(t, err)
+		assert.True(t, processed)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `spec:
+  engine: {{ .Values.myAppDb.database.engine | quote }}
+  parameters: {{- toYaml .Values.myAppDb.database.parameters | nindent 4 }}
+  replicas: {{ .Values.myAppDb.database.replicas }}
+  storage:
+    encrypted: {{ .Values.myAppDb.database.storage.encrypted }}
+    size: {{ .Values.myAppDb.database.storage.size | quote }}
+  unknown: kept
+  users: {{- toYaml .Values.myAppDb.database.users | nindent 4 }}`)
+		assert.NotContains(t, buf.String(), "status")
+
+		assert.Equal(t, helmify.Values{"myAppDb": map[string]interface{}{"database": map[string]interface{}{
+			"engine":     "postgres",
+			"replicas":   int64(2),
+			"storage":    map[string]interface{}{"size": "10Gi", "encrypted": true},
+			"users":      []interface{}{"app"},
+			"parameters": map[string]interface{}{"max_connections": "100"},
+		}}}, tmpl.Values())
+
+		schema := tmpl.(helmify.ValuesSchema).ValuesSchema()
+		spec := schema["myAppDb"].(map[string]interface{})["properties"].(map[string]interface{})["database"].(map[string]interface{})
+		assert.Equal(t, []interface{}{"engine"}, spec["required"])
+		props := spec["properties"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"postgres", "mysql"}}, props["engine"])
+		assert.Equal(t, map[string]interface{}{"type": "integer", "minimum": int64(1)}, props["replicas"])
+		assert.Equal(t, map[string]interface{}{"type": "object"}, props["parameters"])
+		assert.Equal(t, []interface{}{"size"}, props["storage"].(map[string]interface{})["required"])
+		assert.NotContains(t, props, "unknown")
+	})
+}
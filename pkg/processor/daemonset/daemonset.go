@@ -32,7 +32,8 @@ spec:
     metadata:
       labels:
 {{ .PodLabels }}
-{{- .PodAnnotations }}
+      annotations:
+        {{ .PodAnnotations }}
     spec:
 {{ .Spec }}`)
 
@@ -81,28 +82,8 @@ func (d daemonset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 	selector = strings.Trim(selector, " \n")
 	selector = string(yamlformat.Indent([]byte(selector), 4))
 
-	podLabels, err := yamlformat.Marshal(dae.Spec.Template.ObjectMeta.Labels, 8)
-	if err != nil {
-		return true, nil, err
-	}
-	podLabels += fmt.Sprintf("\n      {{- include \"%s.selectorLabels\" . | nindent 8 }}", appMeta.ChartName())
-
-	podAnnotations := ""
-	if len(dae.Spec.Template.ObjectMeta.Annotations) != 0 {
-		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": dae.Spec.Template.ObjectMeta.Annotations}, 6)
-		if err != nil {
-			return true, nil, err
-		}
-
-		podAnnotations = "\n" + podAnnotations
-	}
-
 	nameCamel := strcase.ToLowerCamel(name)
-	specMap, podValues, err := pod.ProcessSpec(nameCamel, appMeta, dae.Spec.Template.Spec)
-	if err != nil {
-		return true, nil, err
-	}
-	err = values.Merge(podValues)
+	podLabels, podAnnotations, specMap, err := pod.ProcessTemplate(nameCamel, appMeta, obj, dae.Spec.Template, dae.Spec.Selector.MatchLabels, &values, 8)
 	if err != nil {
 		return true, nil, err
 	}
@@ -112,6 +93,7 @@ func (d daemonset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstru
 		return true, nil, err
 	}
 	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.RenderPlaceholders(spec)
 
 	return true, &result{
 		values: values,
@@ -33,7 +33,7 @@ func (d dft) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		"ApiVersion": obj.GetAPIVersion(),
 		"Kind":       obj.GetKind(),
 		"Name":       obj.GetName(),
-	}).Warn("Unsupported resource: using default processor.")
+	}).Info("Unsupported resource: using default processor.")
 	name := appMeta.TrimName(obj.GetName())
 
 	meta, err := ProcessObjMeta(appMeta, obj)
@@ -39,7 +39,8 @@ spec:
     metadata:
       labels:
 {{ .PodLabels }}
-{{- .PodAnnotations }}
+      annotations:
+        {{ .PodAnnotations }}
     spec:
 {{ .Spec }}`)
 
@@ -98,28 +99,8 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 	selector = strings.Trim(selector, " \n")
 	selector = string(yamlformat.Indent([]byte(selector), 4))
 
-	podLabels, err := yamlformat.Marshal(depl.Spec.Template.ObjectMeta.Labels, 8)
-	if err != nil {
-		return true, nil, err
-	}
-	podLabels += fmt.Sprintf("\n      {{- include \"%s.selectorLabels\" . | nindent 8 }}", appMeta.ChartName())
-
-	podAnnotations := ""
-	if len(depl.Spec.Template.ObjectMeta.Annotations) != 0 {
-		podAnnotations, err = yamlformat.Marshal(map[string]interface{}{"annotations": depl.Spec.Template.ObjectMeta.Annotations}, 6)
-		if err != nil {
-			return true, nil, err
-		}
-
-		podAnnotations = "\n" + podAnnotations
-	}
-
 	nameCamel := strcase.ToLowerCamel(name)
-	specMap, podValues, err := pod.ProcessSpec(nameCamel, appMeta, depl.Spec.Template.Spec)
-	if err != nil {
-		return true, nil, err
-	}
-	err = values.Merge(podValues)
+	podLabels, podAnnotations, specMap, err := pod.ProcessTemplate(nameCamel, appMeta, obj, depl.Spec.Template, depl.Spec.Selector.MatchLabels, &values, 8)
 	if err != nil {
 		return true, nil, err
 	}
@@ -130,6 +111,7 @@ func (d deployment) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstr
 	}
 
 	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.RenderPlaceholders(spec)
 
 	return true, &result{
 		values: values,
@@ -1,6 +1,7 @@
 package deployment
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/arttor/helmify/pkg/metadata"
@@ -114,6 +115,58 @@ spec:
 `
 )
 
+const strDeplProjected = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+      volumes:
+      - name: vault-token
+        projected:
+          sources:
+          - serviceAccountToken:
+              audience: vault
+              expirationSeconds: 3600
+              path: token
+          - configMap:
+              name: my-app-ca
+              items:
+              - key: ca.crt
+                path: ca.crt`
+
+const strDeplResize = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+        resizePolicy:
+        - resourceName: cpu
+          restartPolicy: NotRequired
+      - name: sidecar
+        image: busybox:1.36`
+
 func Test_deployment_Process(t *testing.T) {
 	var testInstance deployment
 
@@ -129,4 +182,49 @@ func Test_deployment_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
+	t.Run("projected service account token", func(t *testing.T) {
+		obj := internal.GenerateObj(strDeplProjected)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "audience: {{ .Values.myAppWeb.volumes.vaultToken.serviceAccountToken.audience")
+		assert.Contains(t, buf.String(), "expirationSeconds: {{ .Values.myAppWeb.volumes.vaultToken.serviceAccountToken.expirationSeconds")
+		assert.Contains(t, buf.String(), `          - configMap:
+              items:
+              - key: ca.crt
+                path: ca.crt
+              name: my-app-ca`)
+		token := tmpl.Values()["myAppWeb"].(map[string]interface{})["volumes"].(map[string]interface{})["vaultToken"]
+		assert.Equal(t, map[string]interface{}{
+			"serviceAccountToken": map[string]interface{}{
+				"audience":          "vault",
+				"expirationSeconds": int64(3600),
+			},
+		}, token)
+	})
+	t.Run("resize policy", func(t *testing.T) {
+		obj := internal.GenerateObj(strDeplResize)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- if semverCompare ">=1.27-0" .Capabilities.KubeVersion.Version }}
+        resizePolicy: {{- toYaml .Values.myAppWeb.web.resizePolicy | nindent 8 }}
+{{- end }}`)
+		assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("resizePolicy:")))
+		values := tmpl.Values()["myAppWeb"].(map[string]interface{})
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"resourceName": "cpu", "restartPolicy": "NotRequired"},
+		}, values["web"].(map[string]interface{})["resizePolicy"])
+		assert.NotContains(t, values["sidecar"], "resizePolicy")
+	})
+	t.Run("no resize policy", func(t *testing.T) {
+		obj := internal.GenerateObj(strDeplProjected)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.NotContains(t, buf.String(), "resizePolicy")
+	})
 }
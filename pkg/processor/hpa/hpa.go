@@ -0,0 +1,147 @@
+package hpa
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/arttor/helmify/pkg/warning"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var hpaTempl, _ = template.New("hpa").Parse(
+	`{{ .Meta }}
+{{ .Spec }}`)
+
+// hpaGK matches HorizontalPodAutoscaler of any autoscaling version, e.g. v1 and v2.
+var hpaGK = schema.GroupKind{
+	Group: "autoscaling",
+	Kind:  "HorizontalPodAutoscaler",
+}
+
+// New creates processor for k8s HorizontalPodAutoscaler resource.
+func New() helmify.Processor {
+	return &hpa{}
+}
+
+type hpa struct{}
+
+// Process k8s HorizontalPodAutoscaler object into template. Returns false if not capable of processing given resource type.
+func (h hpa) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind().GroupKind() != hpaGK {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	specMap, exists, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get hpa spec", err)
+	}
+	if !exists {
+		return true, nil, fmt.Errorf("no hpa spec presented")
+	}
+
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	err = processScaleTargetRef(appMeta, obj.GetName(), specMap)
+	if err != nil {
+		return true, nil, err
+	}
+
+	values := helmify.Values{}
+	// targetCPUUtilizationPercentage is set only by autoscaling/v1
+	for _, field := range []string{"minReplicas", "maxReplicas", "targetCPUUtilizationPercentage"} {
+		val, ok, _ := unstructured.NestedInt64(specMap, field)
+		if !ok {
+			continue
+		}
+		specMap[field], err = values.Add(val, nameCamel, field)
+		if err != nil {
+			return true, nil, err
+		}
+	}
+	for _, field := range []string{"metrics", "behavior"} {
+		val, ok := specMap[field]
+		if !ok {
+			continue
+		}
+		err = unstructured.SetNestedField(values, val, nameCamel, field)
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable to set hpa %s value", err, field)
+		}
+		specMap[field] = fmt.Sprintf(`{{- toYaml .Values.%s.%s | nindent 4 }}`, nameCamel, field)
+	}
+
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+
+	return true, &result{
+		name: name,
+		data: struct {
+			Meta string
+			Spec string
+		}{Meta: meta, Spec: spec},
+		values: values,
+	}, nil
+}
+
+// processScaleTargetRef rewrites scaleTargetRef apiVersion, kind and name to match the templated chart workload.
+// Target outside the chart is left as it is or rejected in strict mode.
+func processScaleTargetRef(appMeta helmify.AppMetadata, hpaName string, specMap map[string]interface{}) error {
+	targetName, _, _ := unstructured.NestedString(specMap, "scaleTargetRef", "name")
+	gvk, found := appMeta.Workload(targetName)
+	if !found {
+		if appMeta.Config().Strict {
+			return fmt.Errorf("strict mode: hpa %s scale target %q not found in chart", hpaName, targetName)
+		}
+		warning.Report(logrus.WithFields(logrus.Fields{
+			"HorizontalPodAutoscaler": hpaName,
+			"Target":                  targetName,
+		}), "HPA scale target not found in chart: keeping scaleTargetRef as it is.")
+		return nil
+	}
+	logrus.WithFields(logrus.Fields{
+		"HorizontalPodAutoscaler": hpaName,
+		"Target":                  gvk.Kind + "/" + targetName,
+	}).Debug("scale target found in chart: templated")
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	return unstructured.SetNestedStringMap(specMap, map[string]string{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"name":       appMeta.TemplatedName(targetName),
+	}, "scaleTargetRef")
+}
+
+type result struct {
+	name string
+	data struct {
+		Meta string
+		Spec string
+	}
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	return hpaTempl.Execute(writer, r.data)
+}
@@ -0,0 +1,152 @@
+package hpa
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	hpaYaml = `apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app-db
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1beta1
+    kind: Deployment
+    name: my-app-db
+  minReplicas: 1
+  maxReplicas: 5
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: 80`
+
+	hpaV1Yaml = `apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: my-app-web
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: my-app-web
+  minReplicas: 2
+  maxReplicas: 4
+  targetCPUUtilizationPercentage: 70`
+
+	stsYaml = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: db`
+
+	rcYaml = `apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: my-app-db
+spec:
+  selector:
+    app: db`
+
+	deployYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec: {}`
+)
+
+func Test_hpa_Process(t *testing.T) {
+	var testInstance hpa
+
+	newMeta := func(conf config.Config, objYaml ...string) helmify.AppMetadata {
+		appMeta := metadata.New(conf)
+		for _, y := range objYaml {
+			appMeta.Load(internal.GenerateObj(y))
+		}
+		return appMeta
+	}
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(hpaYaml)
+		processed, _, err := testInstance.Process(newMeta(config.Config{ChartName: "chart"}, hpaYaml), obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("statefulset target", func(t *testing.T) {
+		obj := internal.GenerateObj(hpaYaml)
+		appMeta := newMeta(config.Config{ChartName: "chart"}, stsYaml, deployYaml, hpaYaml)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: StatefulSet
+    name: {{ include "chart.fullname" . }}-db`)
+		assert.Contains(t, buf.String(), "maxReplicas: {{ .Values.db.maxReplicas }}")
+		assert.Contains(t, buf.String(), "metrics: {{- toYaml .Values.db.metrics | nindent 4 }}")
+
+		assert.Equal(t, int64(1), tmpl.Values()["db"].(map[string]interface{})["minReplicas"])
+		assert.Equal(t, int64(5), tmpl.Values()["db"].(map[string]interface{})["maxReplicas"])
+	})
+	t.Run("target not in chart", func(t *testing.T) {
+		obj := internal.GenerateObj(hpaYaml)
+		_, tmpl, err := testInstance.Process(newMeta(config.Config{ChartName: "chart"}, deployYaml, hpaYaml), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "    kind: Deployment\n    name: my-app-db")
+
+		obj = internal.GenerateObj(hpaYaml)
+		_, _, err = testInstance.Process(newMeta(config.Config{ChartName: "chart", Strict: true}, deployYaml, hpaYaml), obj)
+		assert.ErrorContains(t, err, `scale target "my-app-db" not found`)
+	})
+	t.Run("autoscaling v1", func(t *testing.T) {
+		obj := internal.GenerateObj(hpaV1Yaml)
+		processed, tmpl, err := testInstance.Process(newMeta(config.Config{ChartName: "chart"}, stsYaml, deployYaml, hpaV1Yaml), obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "apiVersion: autoscaling/v1")
+		assert.Contains(t, buf.String(), `name: {{ include "chart.fullname" . }}-web`)
+		assert.Contains(t, buf.String(), "targetCPUUtilizationPercentage: {{ .Values.web.targetCPUUtilizationPercentage }}")
+
+		assert.Equal(t, int64(70), tmpl.Values()["web"].(map[string]interface{})["targetCPUUtilizationPercentage"])
+	})
+	t.Run("converted replication controller target", func(t *testing.T) {
+		for _, convert := range []bool{false, true} {
+			obj := internal.GenerateObj(hpaYaml)
+			appMeta := newMeta(config.Config{ChartName: "chart", Strict: true, ConvertReplicationControllers: convert}, rcYaml, deployYaml, hpaYaml)
+			_, tmpl, err := testInstance.Process(appMeta, obj)
+			assert.NoError(t, err)
+
+			buf := bytes.Buffer{}
+			assert.NoError(t, tmpl.Write(&buf))
+			expected := "apiVersion: v1\n    kind: ReplicationController"
+			if convert {
+				expected = "apiVersion: apps/v1\n    kind: Deployment"
+			}
+			assert.Contains(t, buf.String(), "  scaleTargetRef:\n    "+expected+"\n"+`    name: {{ include "chart.fullname" . }}-db`)
+		}
+	})
+}
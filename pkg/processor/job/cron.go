@@ -18,7 +18,12 @@ import (
 
 var cronTempl, _ = template.New("cron").Parse(
 	`{{ .Meta }}
-{{ .Spec }}`)
+{{ .Spec }}
+{{- if .TimeZone }}
+{{ .TimeZone }}
+{{- end }}`)
+
+const timeZoneTempl = `  timeZone: {{ .Values.%[1]s.timeZone | quote }}`
 
 var cronGVC = schema.GroupVersionKind{
 	Group:   "batch",
@@ -90,11 +95,9 @@ func (p cron) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 		}
 	}
 
-	if spec.TimeZone != nil {
-		err := templateSpecVal(*spec.TimeZone, &values, specMap, nameCamelCase, "timeZone")
-		if err != nil {
-			return true, nil, err
-		}
+	timeZone, err := processTimeZone(nameCamelCase, spec, specMap, &values)
+	if err != nil {
+		return true, nil, err
 	}
 
 	if spec.SuccessfulJobsHistoryLimit != nil {
@@ -105,41 +108,53 @@ func (p cron) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 	}
 
 	// process job pod template:
-	podSpecMap, podValues, err := pod.ProcessSpec(nameCamelCase, appMeta, jobObj.Spec.JobTemplate.Spec.Template.Spec)
-	if err != nil {
-		return true, nil, err
+	var selector map[string]string
+	if jobObj.Spec.JobTemplate.Spec.Selector != nil {
+		selector = jobObj.Spec.JobTemplate.Spec.Selector.MatchLabels
 	}
-	err = values.Merge(podValues)
+	err = pod.ProcessTemplateSpec(nameCamelCase, appMeta, obj, jobObj.Spec.JobTemplate.Spec.Template, selector, specMap, &values, 12, "jobTemplate", "spec", "template")
 	if err != nil {
 		return true, nil, err
 	}
 
-	err = unstructured.SetNestedMap(specMap, podSpecMap, "jobTemplate", "spec", "template", "spec")
-	if err != nil {
-		return true, nil, fmt.Errorf("%w: unable to template job spec", err)
-	}
-
 	specStr, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
 	if err != nil {
 		return true, nil, err
 	}
 	specStr = strings.ReplaceAll(specStr, "'", "")
+	specStr = pod.RenderPlaceholders(specStr)
 
 	return true, &resultCron{
 		name: name + ".yaml",
 		data: struct {
-			Meta string
-			Spec string
-		}{Meta: meta, Spec: specStr},
+			Meta     string
+			Spec     string
+			TimeZone string
+		}{Meta: meta, Spec: specStr, TimeZone: timeZone},
 		values: values,
 	}, nil
 }
 
+// processTimeZone moves timeZone from spec to values.
+// The field is rendered only for k8s >= 1.27 where it is stable, older clusters reject it.
+func processTimeZone(nameCamel string, spec batchv1.CronJobSpec, specMap map[string]interface{}, values *helmify.Values) (string, error) {
+	delete(specMap, "timeZone")
+	if spec.TimeZone == nil {
+		return "", nil
+	}
+	err := unstructured.SetNestedField(*values, *spec.TimeZone, nameCamel, "timeZone")
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set cronjob timeZone value", err)
+	}
+	return processor.KubeVersionGuard("1.27", fmt.Sprintf(timeZoneTempl, nameCamel)), nil
+}
+
 type resultCron struct {
 	name string
 	data struct {
-		Meta string
-		Spec string
+		Meta     string
+		Spec     string
+		TimeZone string
 	}
 	values helmify.Values
 }
@@ -1,10 +1,13 @@
 package job
 
 import (
+	"bytes"
+	"testing"
+
 	"github.com/arttor/helmify/internal"
 	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/arttor/helmify/pkg/processor/deployment"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 const (
@@ -27,6 +30,40 @@ spec:
                 - -c
                 - date; echo Hello from the Kubernetes cluster
           restartPolicy: OnFailure`
+
+	strCronTimeZone = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cron-job
+spec:
+  schedule: "0 3 * * *"
+  timeZone: Europe/Berlin
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: hello
+              image: busybox:1.28
+          restartPolicy: OnFailure`
+
+	strCronResize = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cron-job
+spec:
+  schedule: "0 3 * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: hello
+              image: busybox:1.28
+              resizePolicy:
+                - resourceName: memory
+                  restartPolicy: RestartContainer
+          restartPolicy: OnFailure`
 )
 
 func Test_Cron_Process(t *testing.T) {
@@ -44,4 +81,76 @@ func Test_Cron_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
+	t.Run("time zone", func(t *testing.T) {
+		obj := internal.GenerateObj(strCronTimeZone)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- if semverCompare ">=1.27-0" .Capabilities.KubeVersion.Version }}
+  timeZone: {{ .Values.cronJob.timeZone | quote }}
+{{- end }}`)
+		assert.Equal(t, "Europe/Berlin", tmpl.Values()["cronJob"].(map[string]interface{})["timeZone"])
+	})
+	t.Run("resize policy", func(t *testing.T) {
+		obj := internal.GenerateObj(strCronResize)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- if semverCompare ">=1.27-0" .Capabilities.KubeVersion.Version }}
+            resizePolicy: {{- toYaml .Values.cronJob.hello.resizePolicy | nindent 12 }}
+{{- end }}`)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"resourceName": "memory", "restartPolicy": "RestartContainer"},
+		}, tmpl.Values()["cronJob"].(map[string]interface{})["hello"].(map[string]interface{})["resizePolicy"])
+	})
+	t.Run("no time zone", func(t *testing.T) {
+		obj := internal.GenerateObj(strCron)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.NotContains(t, buf.String(), "timeZone")
+		assert.NotContains(t, tmpl.Values()["cronJob"], "timeZone")
+	})
+}
+
+const strDeplHello = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cron-job
+spec:
+  selector:
+    matchLabels:
+      app: hello
+  template:
+    metadata:
+      labels:
+        app: hello
+    spec:
+      containers:
+        - name: hello
+          image: busybox:1.28
+          imagePullPolicy: IfNotPresent`
+
+func Test_Cron_ContainerParity(t *testing.T) {
+	_, cronTmpl, err := cron{}.Process(&metadata.Service{}, internal.GenerateObj(strCron))
+	assert.NoError(t, err)
+	_, deplTmpl, err := deployment.New().Process(&metadata.Service{}, internal.GenerateObj(strDeplHello))
+	assert.NoError(t, err)
+
+	cronContainer := cronTmpl.Values()["cronJob"].(map[string]interface{})["hello"].(map[string]interface{})
+	deplContainer := deplTmpl.Values()["cronJob"].(map[string]interface{})["hello"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"repository": "busybox", "tag": "1.28"}, cronContainer["image"])
+	assert.Equal(t, deplContainer["image"], cronContainer["image"])
+	assert.Equal(t, deplContainer["imagePullPolicy"], cronContainer["imagePullPolicy"])
+
+	imageTempl := "image: {{ .Values.cronJob.hello.image.repository }}:{{ .Values.cronJob.hello.image.tag"
+	buf := bytes.Buffer{}
+	assert.NoError(t, cronTmpl.Write(&buf))
+	assert.Contains(t, buf.String(), imageTempl)
+	buf.Reset()
+	assert.NoError(t, deplTmpl.Write(&buf))
+	assert.Contains(t, buf.String(), imageTempl)
 }
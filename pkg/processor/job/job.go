@@ -97,32 +97,22 @@ func (p job) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		}
 	}
 
-	if spec.ActiveDeadlineSeconds != nil {
-		err := templateSpecVal(*spec.ActiveDeadlineSeconds, &values, specMap, nameCamelCase, "activeDeadlineSeconds")
-		if err != nil {
-			return true, nil, err
-		}
-	}
 	// process job pod template:
-	podSpecMap, podValues, err := pod.ProcessSpec(nameCamelCase, appMeta, jobObj.Spec.Template.Spec)
-	if err != nil {
-		return true, nil, err
+	var selector map[string]string
+	if jobObj.Spec.Selector != nil {
+		selector = jobObj.Spec.Selector.MatchLabels
 	}
-	err = values.Merge(podValues)
+	err = pod.ProcessTemplateSpec(nameCamelCase, appMeta, obj, jobObj.Spec.Template, selector, specMap, &values, 8, "template")
 	if err != nil {
 		return true, nil, err
 	}
 
-	err = unstructured.SetNestedMap(specMap, podSpecMap, "template", "spec")
-	if err != nil {
-		return true, nil, fmt.Errorf("%w: unable to template job spec", err)
-	}
-
 	specStr, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
 	if err != nil {
 		return true, nil, err
 	}
 	specStr = strings.ReplaceAll(specStr, "'", "")
+	specStr = pod.RenderPlaceholders(specStr)
 
 	return true, &result{
 		name: name + ".yaml",
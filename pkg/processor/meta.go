@@ -2,9 +2,12 @@ package processor
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
@@ -17,20 +20,34 @@ kind: %[2]s
 metadata:
   name: %[3]s
   labels:
-%[5]s
-  {{- include "%[4]s.labels" . | nindent 4 }}
-%[6]s`
+    {{- include "%[4]s.objectLabels" (dict%[5]s "context" $) | nindent 4 }}
+%[6]s
+%[7]s`
 
 const annotationsTemplate = `  annotations:
-    {{- toYaml .Values.%[1]s.%[2]s.annotations | nindent 4 }}`
+    {{- include "%[3]s.annotations" (dict "annotations" .Values.%[1]s.%[2]s.annotations "context" $) | nindent 4 }}`
+
+// extraAnnotationsTemplate renders annotations from values with user extraAnnotations merged on top.
+// Extra annotations take precedence over defaults, so defaults don't need to be respecified to add or override a key.
+const extraAnnotationsTemplate = `  annotations:
+    {{- include "%[3]s.annotations" (dict "annotations" .Values.%[1]s.%[2]s.annotations "extra" .Values.%[1]s.%[2]s.extraAnnotations "context" $) | nindent 4 }}`
+
+const commonAnnotationsTemplate = `
+    {{- include "%[1]s.commonAnnotations" . | nindent 4 }}`
+
+const onlyCommonAnnotationsTemplate = `  {{- if .Values.commonAnnotations }}
+  annotations:
+    {{- include "%[1]s.commonAnnotations" . | nindent 4 }}
+  {{- end }}`
 
 type MetaOpt interface {
 	apply(*options)
 }
 
 type options struct {
-	values      helmify.Values
-	annotations bool
+	values           helmify.Values
+	annotations      bool
+	extraAnnotations bool
 }
 
 type annotationsOption struct {
@@ -48,15 +65,34 @@ func WithAnnotations(values helmify.Values) MetaOpt {
 	}
 }
 
+type extraAnnotationsOption struct {
+	values helmify.Values
+}
+
+func (a extraAnnotationsOption) apply(opts *options) {
+	opts.annotations = true
+	opts.extraAnnotations = true
+	opts.values = a.values
+}
+
+// WithExtraAnnotations - same as WithAnnotations, but adds empty <name>.<kind>.extraAnnotations value merged on top
+// of annotations from values.
+func WithExtraAnnotations(values helmify.Values) MetaOpt {
+	return extraAnnotationsOption{
+		values: values,
+	}
+}
+
 // ProcessObjMeta - returns object apiVersion, kind and metadata as helm template.
 func ProcessObjMeta(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, opts ...MetaOpt) (string, error) {
 	options := &options{}
 	for _, opt := range opts {
 		opt.apply(options)
 	}
+	injectMetadata(appMeta, obj)
 
 	var err error
-	var labels, annotations string
+	var labels, annotations, finalizers string
 	if len(obj.GetLabels()) != 0 {
 		l := obj.GetLabels()
 		// provided by Helm
@@ -68,10 +104,7 @@ func ProcessObjMeta(appMeta helmify.AppMetadata, obj *unstructured.Unstructured,
 
 		// Since we delete labels above, it is possible that at this point there are no more labels.
 		if len(l) > 0 {
-			labels, err = yamlformat.Marshal(l, 4)
-			if err != nil {
-				return "", err
-			}
+			labels = fmt.Sprintf(` "labels" %s`, dictLiteral(l))
 		}
 	}
 	if len(obj.GetAnnotations()) != 0 {
@@ -81,6 +114,22 @@ func ProcessObjMeta(appMeta helmify.AppMetadata, obj *unstructured.Unstructured,
 		}
 	}
 
+	// finalizers are managed by controllers and can block deletion of chart objects.
+	preserved := preservedFinalizers(appMeta, obj)
+	if len(preserved) != len(obj.GetFinalizers()) {
+		logrus.WithFields(logrus.Fields{
+			"Kind":       obj.GetKind(),
+			"Name":       obj.GetName(),
+			"Finalizers": len(obj.GetFinalizers()) - len(preserved),
+		}).Debug("finalizers not preserved: removed")
+	}
+	if len(preserved) != 0 {
+		finalizers, err = yamlformat.Marshal(map[string]interface{}{"finalizers": preserved}, 2)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	templatedName := appMeta.TemplatedName(obj.GetName())
 	apiVersion, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
 
@@ -97,11 +146,75 @@ func ProcessObjMeta(appMeta helmify.AppMetadata, obj *unstructured.Unstructured,
 			return "", err
 		}
 
-		annotations = fmt.Sprintf(annotationsTemplate, name, kind)
+		annotations = fmt.Sprintf(annotationsTemplate, name, kind, appMeta.ChartName())
+		if options.extraAnnotations {
+			err = unstructured.SetNestedField(options.values, map[string]interface{}{}, name, kind, "extraAnnotations")
+			if err != nil {
+				return "", err
+			}
+			annotations = fmt.Sprintf(extraAnnotationsTemplate, name, kind, appMeta.ChartName())
+		}
+	} else if annotations != "" {
+		annotations += fmt.Sprintf(commonAnnotationsTemplate, appMeta.ChartName())
+	} else {
+		annotations = fmt.Sprintf(onlyCommonAnnotationsTemplate, appMeta.ChartName())
 	}
 
-	metaStr = fmt.Sprintf(metaTemplate, apiVersion, kind, templatedName, appMeta.ChartName(), labels, annotations)
+	metaStr = fmt.Sprintf(metaTemplate, apiVersion, kind, templatedName, appMeta.ChartName(), labels, annotations, finalizers)
 	metaStr = strings.Trim(metaStr, " \n")
 	metaStr = strings.ReplaceAll(metaStr, "\n\n", "\n")
 	return metaStr, nil
 }
+
+// injectMetadata - adds labels and annotations from config to the object metadata.
+func injectMetadata(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) {
+	conf := appMeta.Config()
+	if len(conf.Labels) != 0 {
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		for k, v := range conf.Labels {
+			labels[k] = v
+		}
+		obj.SetLabels(labels)
+	}
+	if len(conf.Annotations) != 0 {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		for k, v := range conf.Annotations {
+			annotations[k] = v
+		}
+		obj.SetAnnotations(annotations)
+	}
+}
+
+// preservedFinalizers - returns object finalizers listed in config to be kept in the chart.
+func preservedFinalizers(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) []string {
+	var res []string
+	for _, f := range obj.GetFinalizers() {
+		for _, preserve := range appMeta.Config().PreserveFinalizers {
+			if f == preserve {
+				res = append(res, f)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// dictLiteral returns template dict literal with given keys and values, e.g. (dict "app" "web").
+func dictLiteral(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	res := "(dict"
+	for _, k := range keys {
+		res += " " + strconv.Quote(k) + " " + strconv.Quote(m[k])
+	}
+	return res + ")"
+}
@@ -14,6 +14,31 @@ func TestProcessObjMeta(t *testing.T) {
 	testMeta.Load(internal.TestNs)
 	res, err := ProcessObjMeta(testMeta, internal.TestNs)
 	assert.NoError(t, err)
-	assert.Contains(t, res, "chart-name.labels")
+	assert.Contains(t, res, "chart-name.objectLabels")
 	assert.Contains(t, res, "chart-name.fullname")
 }
+
+const finalizersYaml = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: my-app-config
+  finalizers:
+    - kubernetes.io/pvc-protection
+    - example.com/cleanup`
+
+func TestProcessObjMeta_Finalizers(t *testing.T) {
+	t.Run("stripped by default", func(t *testing.T) {
+		obj := internal.GenerateObj(finalizersYaml)
+		res, err := ProcessObjMeta(metadata.New(config.Config{ChartName: "chart-name"}), obj)
+		assert.NoError(t, err)
+		assert.NotContains(t, res, "finalizers")
+	})
+	t.Run("preserved", func(t *testing.T) {
+		obj := internal.GenerateObj(finalizersYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart-name", PreserveFinalizers: []string{"example.com/cleanup"}})
+		res, err := ProcessObjMeta(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Contains(t, res, "  finalizers:\n  - example.com/cleanup")
+		assert.NotContains(t, res, "kubernetes.io/pvc-protection")
+	})
+}
@@ -0,0 +1,128 @@
+package monitoring
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var monitorTempl, _ = template.New("monitor").Parse(
+	`{{ .Meta }}
+{{ .Spec }}`)
+
+var serviceMonitorGVC = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "ServiceMonitor",
+}
+
+var podMonitorGVC = schema.GroupVersionKind{
+	Group:   "monitoring.coreos.com",
+	Version: "v1",
+	Kind:    "PodMonitor",
+}
+
+// New creates processor for prometheus-operator ServiceMonitor and PodMonitor resources.
+func New() helmify.Processor {
+	return &monitor{}
+}
+
+type monitor struct{}
+
+// Process ServiceMonitor or PodMonitor object into template. Returns false if not capable of processing given resource type.
+func (m monitor) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	var endpointsKey string
+	switch obj.GroupVersionKind() {
+	case serviceMonitorGVC:
+		endpointsKey = "endpoints"
+	case podMonitorGVC:
+		endpointsKey = "podMetricsEndpoints"
+	default:
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	specMap, exists, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s spec", err, obj.GetKind())
+	}
+	if !exists {
+		return true, nil, fmt.Errorf("no %s spec presented", obj.GetKind())
+	}
+
+	values := helmify.Values{}
+	// template endpoint ports to keep them consistent with Service and container port names:
+	endpoints, _, err := unstructured.NestedSlice(specMap, endpointsKey)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get %s endpoints", err, obj.GetKind())
+	}
+	if len(endpoints) != 0 {
+		err = processor.TemplatePortFields(appMeta, values, endpoints, "port", "targetPort")
+		if err != nil {
+			return true, nil, err
+		}
+		err = unstructured.SetNestedSlice(specMap, endpoints, endpointsKey)
+		if err != nil {
+			return true, nil, err
+		}
+	}
+
+	// monitored objects are installed into release namespace:
+	matchNames, _, _ := unstructured.NestedStringSlice(specMap, "namespaceSelector", "matchNames")
+	for i, ns := range matchNames {
+		if ns == appMeta.Namespace() {
+			matchNames[i] = "{{ .Release.Namespace }}"
+		}
+	}
+	if len(matchNames) != 0 {
+		err = unstructured.SetNestedStringSlice(specMap, matchNames, "namespaceSelector", "matchNames")
+		if err != nil {
+			return true, nil, err
+		}
+	}
+
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+
+	return true, &result{
+		name: appMeta.TrimName(obj.GetName()) + ".yaml",
+		data: struct {
+			Meta string
+			Spec string
+		}{Meta: meta, Spec: spec},
+		values: values,
+	}, nil
+}
+
+type result struct {
+	name string
+	data struct {
+		Meta string
+		Spec string
+	}
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	return monitorTempl.Execute(writer, r.data)
+}
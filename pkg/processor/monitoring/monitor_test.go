@@ -0,0 +1,147 @@
+package monitoring
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/arttor/helmify/pkg/processor/deployment"
+	"github.com/arttor/helmify/pkg/processor/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	svcMonitorYaml = `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: my-app-monitor
+  namespace: my-ns
+spec:
+  endpoints:
+  - port: http
+    path: /metrics
+  namespaceSelector:
+    matchNames:
+    - my-ns
+  selector:
+    matchLabels:
+      app: my-app`
+	podMonitorYaml = `apiVersion: monitoring.coreos.com/v1
+kind: PodMonitor
+metadata:
+  name: my-app-pod-monitor
+  namespace: my-ns
+spec:
+  podMetricsEndpoints:
+  - port: http
+  selector:
+    matchLabels:
+      app: my-app`
+	svcYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-service
+  namespace: my-ns
+spec:
+  ports:
+  - name: http
+    port: 80
+    targetPort: http
+  selector:
+    app: my-app`
+	deplYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-deployment
+  namespace: my-ns
+spec:
+  selector:
+    matchLabels:
+      app: my-app
+  template:
+    metadata:
+      labels:
+        app: my-app
+    spec:
+      containers:
+      - name: app
+        image: my-app:v1
+        ports:
+        - containerPort: 8080
+          name: http
+        livenessProbe:
+          httpGet:
+            path: /healthz
+            port: http`
+)
+
+func Test_monitor_Process(t *testing.T) {
+	var testInstance monitor
+
+	t.Run("processed service monitor", func(t *testing.T) {
+		obj := internal.GenerateObj(svcMonitorYaml)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("processed pod monitor", func(t *testing.T) {
+		obj := internal.GenerateObj(podMonitorYaml)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("port not shared", func(t *testing.T) {
+		obj := internal.GenerateObj(svcMonitorYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart-name"})
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Empty(t, tmpl.Values())
+		assert.Contains(t, render(t, tmpl), "port: http")
+		assert.Contains(t, render(t, tmpl), "- {{ .Release.Namespace }}")
+	})
+}
+
+func Test_sharedPortName(t *testing.T) {
+	objs := map[helmify.Processor]string{
+		service.New():    svcYaml,
+		deployment.New(): deplYaml,
+		New():            svcMonitorYaml,
+	}
+	appMeta := metadata.New(config.Config{ChartName: "chart-name"})
+	for _, objYaml := range objs {
+		appMeta.Load(internal.GenerateObj(objYaml))
+	}
+	values := helmify.Values{}
+	for p, objYaml := range objs {
+		processed, tmpl, err := p.Process(appMeta, internal.GenerateObj(objYaml))
+		assert.NoError(t, err)
+		assert.True(t, processed)
+		assert.NoError(t, values.Merge(tmpl.Values()))
+		if _, isSvc := tmpl.Values()["service"]; isSvc {
+			// service ports are rendered from values with tpl
+			assert.Contains(t, render(t, tmpl), "tpl (toYaml .Values.service.ports)")
+			continue
+		}
+		assert.Contains(t, render(t, tmpl), "port: {{ .Values.portNames.http }}")
+	}
+	assert.Equal(t, "http", values["portNames"].(map[string]interface{})["http"])
+	ports := values["service"].(map[string]interface{})["ports"].([]interface{})
+	assert.Equal(t, "{{ .Values.portNames.http }}", ports[0].(map[string]interface{})["name"])
+	assert.Equal(t, "{{ .Values.portNames.http }}", ports[0].(map[string]interface{})["targetPort"])
+}
+
+func render(t *testing.T, tmpl helmify.Template) string {
+	buf := bytes.Buffer{}
+	assert.NoError(t, tmpl.Write(&buf))
+	return buf.String()
+}
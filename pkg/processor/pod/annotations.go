@@ -0,0 +1,29 @@
+package pod
+
+import (
+	"fmt"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const podAnnotationsTempl = `{{- include "%[1]s.podAnnotations" (dict "annotations" .Values.%[2]s.podAnnotations "context" $) | nindent %[3]d }}`
+
+// ProcessPodAnnotations moves pod template annotations to <objName>.podAnnotations value and returns template
+// for pod template 'annotations' field. Rendered annotations are chart-wide global.podAnnotations
+// with <objName>.podAnnotations merged on top. Indent is the indentation of the annotations content.
+func ProcessPodAnnotations(objName string, appMeta helmify.AppMetadata, annotations map[string]string, values *helmify.Values, indent int) (string, error) {
+	podAnnotations := map[string]interface{}{}
+	for k, v := range annotations {
+		podAnnotations[k] = v
+	}
+	err := unstructured.SetNestedMap(*values, podAnnotations, objName, "podAnnotations")
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set pod annotations value", err)
+	}
+	err = unstructured.SetNestedMap(*values, map[string]interface{}{}, "global", "podAnnotations")
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set global pod annotations value", err)
+	}
+	return fmt.Sprintf(podAnnotationsTempl, appMeta.ChartName(), objName, indent), nil
+}
@@ -0,0 +1,61 @@
+package pod
+
+import (
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/warning"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// imageArch returns CPU architecture shared by all pod images according to ImageArch config.
+// Returns empty string if architecture of some image is unknown or images require different architectures.
+func imageArch(appMeta helmify.AppMetadata, spec corev1.PodSpec) string {
+	known := appMeta.Config().ImageArch
+	if len(known) == 0 {
+		return ""
+	}
+	arch := ""
+	for _, c := range append(append([]corev1.Container{}, spec.InitContainers...), spec.Containers...) {
+		imageArch, ok := known[imageRepository(c.Image)]
+		if !ok {
+			return ""
+		}
+		if arch != "" && arch != imageArch {
+			warning.Report(logrus.WithFields(logrus.Fields{
+				"Image": c.Image,
+				"Arch":  arch + ", " + imageArch,
+			}), "pod images require different architectures: arch nodeSelector skipped")
+			return ""
+		}
+		arch = imageArch
+	}
+	return arch
+}
+
+// processArchNodeSelector adds kubernetes.io/arch key with given architecture to pod nodeSelector,
+// unless the pod already selects nodes by architecture.
+func processArchNodeSelector(arch string, specMap map[string]interface{}) {
+	if arch == "" {
+		return
+	}
+	nodeSelector, _ := specMap["nodeSelector"].(map[string]interface{})
+	if nodeSelector == nil {
+		nodeSelector = map[string]interface{}{}
+	}
+	if _, defined := nodeSelector[corev1.LabelArchStable]; defined {
+		return
+	}
+	nodeSelector[corev1.LabelArchStable] = arch
+	specMap["nodeSelector"] = nodeSelector
+}
+
+// imageRepository returns image reference without tag and digest.
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
@@ -0,0 +1,118 @@
+package pod
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const podLabelsTempl = `
+%[5]s{{- include "%[1]s.selectorLabels" . | nindent %[3]d }}
+%[5]s{{- with omit (.Values.%[2]s.podLabels | default dict)%[4]s }}
+%[5]s{{- toYaml . | nindent %[3]d }}
+%[5]s{{- end }}`
+
+// podLabelsPlaceholder marks position of pod labels template in marshaled pod template labels.
+// Placeholder value is object name, chart name and selector label keys separated by spaces.
+const podLabelsPlaceholder = "helmifyPodLabels"
+
+var podLabelsRe = regexp.MustCompile(`(?m)^( *)` + podLabelsPlaceholder + `: (.+)$`)
+
+// ProcessPodLabels returns template for pod template 'labels' field. Labels used by workload selector are kept
+// in the template as they are. Other labels are moved to <objName>.podLabels value. Selector label keys are omitted
+// from the value, so overridden labels can't desync the pod template from the selector.
+// Indent is the indentation of the labels content.
+func ProcessPodLabels(objName string, appMeta helmify.AppMetadata, labels, selector map[string]string, values *helmify.Values, indent int) (string, error) {
+	selectorLabels, err := processPodLabels(objName, labels, selector, values)
+	if err != nil {
+		return "", err
+	}
+	res := podLabelsTemplate(appMeta.ChartName(), objName, sortedKeys(selector), indent)
+	if len(selectorLabels) == 0 {
+		return strings.TrimPrefix(res, "\n"), nil
+	}
+	static, err := yamlformat.Marshal(selectorLabels, indent)
+	if err != nil {
+		return "", err
+	}
+	return static + res, nil
+}
+
+// processPodLabelsPlaceholder templates pod labels located in specMap under given path. Labels used by workload
+// selector are kept, other labels are replaced with placeholder rendered by RenderPlaceholders.
+func processPodLabelsPlaceholder(objName string, appMeta helmify.AppMetadata, labels, selector map[string]string, specMap map[string]interface{}, values *helmify.Values, path ...string) error {
+	selectorLabels, err := processPodLabels(objName, labels, selector, values)
+	if err != nil {
+		return err
+	}
+	res := map[string]interface{}{
+		podLabelsPlaceholder: strings.Join(append([]string{objName, appMeta.ChartName()}, sortedKeys(selector)...), " "),
+	}
+	for k, v := range selectorLabels {
+		res[k] = v
+	}
+	err = unstructured.SetNestedMap(specMap, res, append(path, "metadata", "labels")...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to template pod labels", err)
+	}
+	return nil
+}
+
+// renderPodLabels replaces pod labels placeholders in marshaled spec with pod labels template.
+func renderPodLabels(spec string) string {
+	return podLabelsRe.ReplaceAllStringFunc(spec, func(line string) string {
+		m := podLabelsRe.FindStringSubmatch(line)
+		args := strings.Split(m[2], " ")
+		objName, chartName := args[0], ""
+		if len(args) > 1 {
+			chartName = args[1]
+		}
+		var selectorKeys []string
+		if len(args) > 2 {
+			selectorKeys = args[2:]
+		}
+		return strings.TrimPrefix(podLabelsTemplate(chartName, objName, selectorKeys, len(m[1])), "\n")
+	})
+}
+
+// processPodLabels moves pod labels not used by workload selector to <objName>.podLabels value.
+// Returns labels used by selector.
+func processPodLabels(objName string, labels, selector map[string]string, values *helmify.Values) (map[string]string, error) {
+	selectorLabels := map[string]string{}
+	podLabels := map[string]interface{}{}
+	for k, v := range labels {
+		if _, ok := selector[k]; ok {
+			selectorLabels[k] = v
+			continue
+		}
+		podLabels[k] = v
+	}
+	err := unstructured.SetNestedMap(*values, podLabels, objName, "podLabels")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to set pod labels value", err)
+	}
+	return selectorLabels, nil
+}
+
+func podLabelsTemplate(chartName, objName string, selectorKeys []string, indent int) string {
+	omitKeys := make([]string, 0, len(selectorKeys))
+	for _, k := range selectorKeys {
+		omitKeys = append(omitKeys, " "+strconv.Quote(k))
+	}
+	return fmt.Sprintf(podLabelsTempl, chartName, objName, indent, strings.Join(omitKeys, ""), strings.Repeat(" ", indent-2))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,21 +2,40 @@ package pod
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/arttor/helmify/pkg/cluster"
 	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
 	securityContext "github.com/arttor/helmify/pkg/processor/security-context"
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const imagePullPolicyTemplate = "{{ .Values.%[1]s.%[2]s.imagePullPolicy }}"
+
 const envValue = "{{ quote .Values.%[1]s.%[2]s.%[3]s.%[4]s }}"
 
+// extraEnvFromTemplate renders user provided envFrom sources of container without generated envFrom entries.
+const extraEnvFromTemplate = "{{ .Values.%s.extraEnvFrom | default list | toJson }}"
+
+// extraEnvFromAppendTemplate appends user provided envFrom sources to generated envFrom entries of yaml flow sequence.
+const extraEnvFromAppendTemplate = "{{- range .Values.%s.extraEnvFrom }}, {{ toJson . }}{{- end }}"
+
+// resizePolicyRe matches container resizePolicy moved to values.
+var resizePolicyRe = regexp.MustCompile(`(?m)^( *)resizePolicy: helmifyResizePolicy\.(\S+)$`)
+
+// resizePolicyPlaceholder marks position of container resizePolicy in marshaled container spec.
+const resizePolicyPlaceholder = "helmifyResizePolicy.%s.%s"
+
 func ProcessSpec(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpec) (map[string]interface{}, helmify.Values, error) {
+	arch := imageArch(appMeta, spec)
 	values, err := processPodSpec(objName, appMeta, &spec)
 	if err != nil {
 		return nil, nil, err
@@ -39,12 +58,32 @@ func ProcessSpec(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpe
 		return nil, nil, fmt.Errorf("%w: unable to convert podSpec to map", err)
 	}
 
-	specMap, values, err = processNestedContainers(specMap, objName, values, "containers")
+	specMap, values, err = processNestedContainers(specMap, objName, appMeta.ChartName(), values, "containers")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	specMap, values, err = processNestedContainers(specMap, objName, appMeta.ChartName(), values, "initContainers")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = processPortNames(appMeta, specMap, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = processProjectedTokens(objName, specMap, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = processCSIVolumes(objName, appMeta, specMap, values)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	specMap, values, err = processNestedContainers(specMap, objName, values, "initContainers")
+	err = processHostname(objName, appMeta, spec, specMap, values)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -61,29 +100,266 @@ func ProcessSpec(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpe
 		return nil, nil, err
 	}
 
-	// process nodeSelector if presented:
-	if spec.NodeSelector != nil {
-		err = unstructured.SetNestedField(specMap, fmt.Sprintf(`{{- toYaml .Values.%s.nodeSelector | nindent 8 }}`, objName), "nodeSelector")
+	err = processTolerations(objName, appMeta, specMap, values)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	processArchNodeSelector(arch, specMap)
+
+	// process nodeSelector and affinity if presented:
+	for _, field := range []string{"nodeSelector", "affinity"} {
+		tpl, err := processor.ExternalizeBlock(values, specMap, objName+"."+field, 8, field)
 		if err != nil {
 			return nil, nil, err
 		}
-		err = unstructured.SetNestedStringMap(values, spec.NodeSelector, objName, "nodeSelector")
-		if err != nil {
-			return nil, nil, err
+		if tpl != "" {
+			specMap[field] = tpl
 		}
 	}
 
 	return specMap, values, nil
 }
 
-func processNestedContainers(specMap map[string]interface{}, objName string, values map[string]interface{}, containerKey string) (map[string]interface{}, map[string]interface{}, error) {
+// processTolerations moves pod tolerations to <objName>.tolerations value rendered with toYaml.
+// With TolerationFields config, the list structure is kept in the template and fields of every toleration are moved
+// to <objName>.tolerations.toleration[N].<field> values, so a single taint key or effect can be overridden.
+func processTolerations(objName string, appMeta helmify.AppMetadata, specMap map[string]interface{}, values helmify.Values) error {
+	if !appMeta.Config().TolerationFields {
+		tpl, err := processor.ExternalizeBlock(values, specMap, objName+".tolerations", 8, "tolerations")
+		if err != nil {
+			return err
+		}
+		if tpl != "" {
+			specMap["tolerations"] = tpl
+		}
+		return nil
+	}
+	tolerations, _, err := unstructured.NestedSlice(specMap, "tolerations")
+	if err != nil {
+		return err
+	}
+	for i, t := range tolerations {
+		toleration, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key := "toleration"
+		if i > 0 {
+			key += strconv.Itoa(i)
+		}
+		for _, field := range []string{"key", "operator", "value", "effect", "tolerationSeconds"} {
+			val, ok := toleration[field]
+			if !ok {
+				continue
+			}
+			toleration[field], err = values.Add(val, objName, "tolerations", key, field)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(tolerations) != 0 {
+		return unstructured.SetNestedSlice(specMap, tolerations, "tolerations")
+	}
+	return nil
+}
+
+// processResizePolicy moves container resizePolicy to <objName>.<container>.resizePolicy values.
+// resizePolicy is unknown to the k8s api version used for pod spec conversion, so it is read from raw pod spec
+// located in obj under given path. The field is rendered only for k8s >= 1.27 where in-place resize is available.
+func processResizePolicy(objName string, obj *unstructured.Unstructured, specMap map[string]interface{}, values *helmify.Values, path ...string) error {
+	rawContainers, _, err := unstructured.NestedSlice(obj.Object, append(path, "containers")...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to get pod containers", err)
+	}
+	policies := map[string]interface{}{}
+	for _, c := range rawContainers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		policy, exists, _ := unstructured.NestedSlice(container, "resizePolicy")
+		if !exists || len(policy) == 0 {
+			continue
+		}
+		name, _, _ := unstructured.NestedString(container, "name")
+		policies[name] = policy
+	}
+	if len(policies) == 0 {
+		return nil
+	}
+
+	containers, _, err := unstructured.NestedSlice(specMap, "containers")
+	if err != nil {
+		return err
+	}
+	for i := range containers {
+		container := containers[i].(map[string]interface{})
+		name, _, _ := unstructured.NestedString(container, "name")
+		policy, ok := policies[name]
+		if !ok {
+			continue
+		}
+		containerName := strcase.ToLowerCamel(name)
+		err = unstructured.SetNestedField(*values, policy, objName, containerName, "resizePolicy")
+		if err != nil {
+			return fmt.Errorf("%w: unable to set container resizePolicy value", err)
+		}
+		container["resizePolicy"] = fmt.Sprintf(resizePolicyPlaceholder, objName, containerName)
+	}
+	return unstructured.SetNestedSlice(specMap, containers, "containers")
+}
+
+// RenderPlaceholders replaces pod spec placeholders in marshaled spec with templates:
+//   - resizePolicy is rendered from values for k8s versions supporting it.
+//   - pod labels are rendered from chart selector labels and values, see ProcessPodLabels.
+func RenderPlaceholders(spec string) string {
+	spec = resizePolicyRe.ReplaceAllStringFunc(spec, func(line string) string {
+		m := resizePolicyRe.FindStringSubmatch(line)
+		return processor.KubeVersionGuard("1.27", fmt.Sprintf("%sresizePolicy: {{- toYaml .Values.%s.resizePolicy | nindent %d }}", m[1], m[2], len(m[1])))
+	})
+	return renderPodLabels(spec)
+}
+
+// processProjectedTokens moves audience and expirationSeconds of projected serviceAccountToken volume sources to values.
+// Other projection sources are kept as they are.
+func processProjectedTokens(objName string, specMap map[string]interface{}, values helmify.Values) error {
+	volumes, _, err := unstructured.NestedSlice(specMap, "volumes")
+	if err != nil {
+		return err
+	}
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		sources, _, _ := unstructured.NestedSlice(volume, "projected", "sources")
+		tokens := 0
+		for _, s := range sources {
+			token, ok, _ := unstructured.NestedMap(s.(map[string]interface{}), "serviceAccountToken")
+			if !ok {
+				continue
+			}
+			key := "serviceAccountToken"
+			if tokens > 0 {
+				key += strconv.Itoa(tokens)
+			}
+			tokens++
+			volName, _ := volume["name"].(string)
+			for _, field := range []string{"audience", "expirationSeconds"} {
+				val, ok := token[field]
+				if !ok {
+					continue
+				}
+				token[field], err = values.Add(val, objName, "volumes", strcase.ToLowerCamel(volName), key, field)
+				if err != nil {
+					return err
+				}
+			}
+			s.(map[string]interface{})["serviceAccountToken"] = token
+		}
+		if tokens != 0 {
+			if err = unstructured.SetNestedSlice(volume, sources, "projected", "sources"); err != nil {
+				return err
+			}
+		}
+	}
+	if len(volumes) != 0 {
+		return unstructured.SetNestedSlice(specMap, volumes, "volumes")
+	}
+	return nil
+}
+
+// processCSIVolumes moves driver specific volumeAttributes of csi volumes to <objName>.volumes.<volume>.volumeAttributes
+// values. Secret referenced by nodePublishSecretRef is templated if it is in the chart, otherwise its name is moved to
+// <objName>.volumes.<volume>.nodePublishSecretRef value.
+func processCSIVolumes(objName string, appMeta helmify.AppMetadata, specMap map[string]interface{}, values helmify.Values) error {
+	volumes, _, err := unstructured.NestedSlice(specMap, "volumes")
+	if err != nil {
+		return err
+	}
+	processed := false
+	for _, v := range volumes {
+		volume, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		csi, ok, _ := unstructured.NestedMap(volume, "csi")
+		if !ok {
+			continue
+		}
+		processed = true
+		volName, _ := volume["name"].(string)
+		volKey := strcase.ToLowerCamel(volName)
+		attributes, _, err := unstructured.NestedStringMap(csi, "volumeAttributes")
+		if err != nil {
+			return fmt.Errorf("%w: unable to get csi volumeAttributes of volume %s", err, volName)
+		}
+		for k, val := range attributes {
+			csi["volumeAttributes"].(map[string]interface{})[k], err = values.Add(val, objName, "volumes", volKey, "volumeAttributes", k)
+			if err != nil {
+				return err
+			}
+		}
+		secretName, ok, _ := unstructured.NestedString(csi, "nodePublishSecretRef", "name")
+		if ok {
+			templated := appMeta.TemplatedName(secretName)
+			if templated == secretName {
+				templated, err = values.Add(secretName, objName, "volumes", volKey, "nodePublishSecretRef")
+				if err != nil {
+					return err
+				}
+			}
+			if err = unstructured.SetNestedField(csi, templated, "nodePublishSecretRef", "name"); err != nil {
+				return err
+			}
+		}
+		volume["csi"] = csi
+	}
+	if processed {
+		return unstructured.SetNestedSlice(specMap, volumes, "volumes")
+	}
+	return nil
+}
+
+// processHostname templates pod DNS settings. Subdomain referencing chart service is templated with
+// the service name, otherwise it is moved to values as well as setHostnameAsFQDN.
+func processHostname(objName string, appMeta helmify.AppMetadata, spec corev1.PodSpec, specMap map[string]interface{}, values helmify.Values) error {
+	if spec.Subdomain != "" {
+		// subdomain names a service, chart objects of other kinds with the same name are not referenced
+		if appMeta.HasObject("Service", spec.Subdomain) {
+			specMap["subdomain"] = appMeta.TemplatedName(spec.Subdomain)
+		} else {
+			logrus.WithFields(logrus.Fields{
+				"Workload":  objName,
+				"Subdomain": spec.Subdomain,
+			}).Debug("subdomain service not in chart: moved to values")
+			subdomain, err := values.Add(spec.Subdomain, objName, "subdomain")
+			if err != nil {
+				return err
+			}
+			specMap["subdomain"] = subdomain
+		}
+	}
+	if spec.SetHostnameAsFQDN != nil {
+		fqdn, err := values.Add(*spec.SetHostnameAsFQDN, objName, "setHostnameAsFQDN")
+		if err != nil {
+			return err
+		}
+		specMap["setHostnameAsFQDN"] = fqdn
+	}
+	return nil
+}
+
+func processNestedContainers(specMap map[string]interface{}, objName, chartName string, values map[string]interface{}, containerKey string) (map[string]interface{}, map[string]interface{}, error) {
 	containers, _, err := unstructured.NestedSlice(specMap, containerKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	if len(containers) > 0 {
-		containers, values, err = processContainers(objName, values, containerKey, containers)
+		containers, values, err = processContainers(objName, chartName, values, containerKey, containers)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -97,39 +373,180 @@ func processNestedContainers(specMap map[string]interface{}, objName string, val
 	return specMap, values, nil
 }
 
-func processContainers(objName string, values helmify.Values, containerType string, containers []interface{}) ([]interface{}, helmify.Values, error) {
+// processPortNames templates container and probe port names shared with other chart objects.
+func processPortNames(appMeta helmify.AppMetadata, specMap map[string]interface{}, values helmify.Values) error {
+	for _, containerKey := range []string{"containers", "initContainers"} {
+		containers, exists, err := unstructured.NestedSlice(specMap, containerKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+		err = processor.TemplateContainerPorts(appMeta, values, containers)
+		if err != nil {
+			return err
+		}
+		err = unstructured.SetNestedSlice(specMap, containers, containerKey)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func processContainers(objName, chartName string, values helmify.Values, containerType string, containers []interface{}) ([]interface{}, helmify.Values, error) {
 	for i := range containers {
-		containerName := strcase.ToLowerCamel((containers[i].(map[string]interface{})["name"]).(string))
-		res, exists, err := unstructured.NestedMap(values, objName, containerName, "resources")
+		container := containers[i].(map[string]interface{})
+		containerName := strcase.ToLowerCamel(container["name"].(string))
+		valuePath := objName + "." + containerName
+
+		resources, err := processor.ExternalizeBlock(values, container, valuePath+".resources", 10, "resources")
 		if err != nil {
 			return nil, nil, err
 		}
-		if exists && len(res) > 0 {
-			err = unstructured.SetNestedField(containers[i].(map[string]interface{}), fmt.Sprintf(`{{- toYaml .Values.%s.%s.resources | nindent 10 }}`, objName, containerName), "resources")
-			if err != nil {
-				return nil, nil, err
-			}
+		if resources != "" {
+			// resources helper drops requests or limits set to null
+			container["resources"] = fmt.Sprintf(`{{- include "%s.resources" .Values.%s.resources | nindent 10 }}`, chartName, valuePath)
 		}
 
-		args, exists, err := unstructured.NestedStringSlice(containers[i].(map[string]interface{}), "args")
+		args, err := processor.ExternalizeBlock(values, container, valuePath+".args", 8, "args")
 		if err != nil {
 			return nil, nil, err
 		}
-		if exists && len(args) > 0 {
-			err = unstructured.SetNestedField(containers[i].(map[string]interface{}), fmt.Sprintf(`{{- toYaml .Values.%[1]s.%[2]s.args | nindent 8 }}`, objName, containerName), "args")
-			if err != nil {
-				return nil, nil, err
-			}
+		if args != "" {
+			container["args"] = args
+		}
 
-			err = unstructured.SetNestedStringSlice(values, args, objName, containerName, "args")
-			if err != nil {
-				return nil, nil, fmt.Errorf("%w: unable to set deployment value field", err)
-			}
+		err = processStartupProbe(objName, containerName, container, values)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = processDebugFields(objName, containerName, container, values)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = processWorkingDir(objName, containerName, container, values)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = processExtraEnvFrom(objName, container)
+		if err != nil {
+			return nil, nil, err
 		}
 	}
+	err := unstructured.SetNestedSlice(values, []interface{}{}, objName, "extraEnvFrom")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: unable to set extraEnvFrom value", err)
+	}
 	return containers, values, nil
 }
 
+// processExtraEnvFrom templates container envFrom with sources from <objName>.extraEnvFrom values appended after
+// the generated entries. envFrom is rendered as yaml flow sequence, so the template doesn't depend on container
+// indentation in the spec.
+func processExtraEnvFrom(objName string, container map[string]interface{}) error {
+	envFrom, _, err := unstructured.NestedSlice(container, "envFrom")
+	if err != nil {
+		return fmt.Errorf("%w: unable to get container envFrom", err)
+	}
+	if len(envFrom) == 0 {
+		container["envFrom"] = fmt.Sprintf(extraEnvFromTemplate, objName)
+		return nil
+	}
+	entries := make([]string, len(envFrom))
+	for i, e := range envFrom {
+		entry, ok := e.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unexpected container envFrom entry %v", e)
+		}
+		entries[i] = flowMapping(entry)
+	}
+	container["envFrom"] = "[" + strings.Join(entries, ", ") + fmt.Sprintf(extraEnvFromAppendTemplate, objName) + "]"
+	return nil
+}
+
+// flowMapping renders map as yaml flow mapping with sorted keys. Scalars are written as they are, so templated
+// values are kept unquoted.
+func flowMapping(m map[string]interface{}) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	fields := make([]string, len(keys))
+	for i, k := range keys {
+		if nested, ok := m[k].(map[string]interface{}); ok {
+			fields[i] = k + ": " + flowMapping(nested)
+			continue
+		}
+		fields[i] = fmt.Sprintf("%s: %v", k, m[k])
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+// startupProbeDefaults - k8s defaults of startup probe fields defining the boot budget.
+var startupProbeDefaults = map[string]int64{"failureThreshold": 3, "periodSeconds": 10}
+
+// processStartupProbe moves startup probe failureThreshold and periodSeconds to
+// <objName>.<containerName>.startupProbe values. Their product is the time budget given to the container to start.
+// Fields not set in the probe get k8s defaults, so the budget is always adjustable with values.
+func processStartupProbe(objName, containerName string, container map[string]interface{}, values helmify.Values) error {
+	probe, ok := container["startupProbe"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	for _, field := range []string{"failureThreshold", "periodSeconds"} {
+		val, ok := probe[field]
+		if !ok {
+			val = startupProbeDefaults[field]
+		}
+		tpl, err := values.Add(val, objName, containerName, "startupProbe", field)
+		if err != nil {
+			return err
+		}
+		probe[field] = tpl
+	}
+	return nil
+}
+
+// debugFields - container fields of interactive and debug workloads.
+var debugFields = []string{"stdin", "stdinOnce", "tty"}
+
+// processDebugFields moves stdin, stdinOnce and tty container fields to <objName>.<containerName> values.
+// Fields are moved only if set in the input, unset fields are omitted from the template.
+func processDebugFields(objName, containerName string, container map[string]interface{}, values helmify.Values) error {
+	for _, field := range debugFields {
+		val, ok := container[field]
+		if !ok {
+			continue
+		}
+		tpl, err := values.Add(val, objName, containerName, field)
+		if err != nil {
+			return err
+		}
+		container[field] = tpl
+	}
+	return nil
+}
+
+// processWorkingDir moves container workingDir to values only if set, otherwise the image default applies.
+func processWorkingDir(objName, containerName string, container map[string]interface{}, values helmify.Values) error {
+	workingDir, ok := container["workingDir"]
+	if !ok {
+		return nil
+	}
+	tpl, err := values.Add(workingDir, objName, containerName, "workingDir")
+	if err != nil {
+		return fmt.Errorf("%w: unable to set container workingDir", err)
+	}
+	container["workingDir"] = tpl
+	return nil
+}
+
 func processPodSpec(name string, appMeta helmify.AppMetadata, pod *corev1.PodSpec) (helmify.Values, error) {
 	values := helmify.Values{}
 	for i, c := range pod.Containers {
@@ -155,8 +572,27 @@ func processPodSpec(name string, appMeta helmify.AppMetadata, pod *corev1.PodSpe
 		if v.Secret != nil {
 			v.Secret.SecretName = appMeta.TemplatedName(v.Secret.SecretName)
 		}
+		if v.Projected != nil {
+			for _, s := range v.Projected.Sources {
+				if s.ConfigMap != nil {
+					s.ConfigMap.Name = appMeta.TemplatedName(s.ConfigMap.Name)
+				}
+				if s.Secret != nil {
+					s.Secret.Name = appMeta.TemplatedName(s.Secret.Name)
+				}
+			}
+		}
 	}
 	pod.ServiceAccountName = appMeta.TemplatedName(pod.ServiceAccountName)
+	// cluster-wide priority classes like system-cluster-critical are kept as they are
+	if appMeta.HasObject("PriorityClass", pod.PriorityClassName) {
+		pod.PriorityClassName = appMeta.TemplatedName(pod.PriorityClassName)
+	} else if pod.PriorityClassName != "" {
+		logrus.WithFields(logrus.Fields{
+			"Workload":      name,
+			"PriorityClass": pod.PriorityClassName,
+		}).Debug("priority class not in chart: name kept")
+	}
 
 	for i, s := range pod.ImagePullSecrets {
 		pod.ImagePullSecrets[i].Name = appMeta.TemplatedName(s.Name)
@@ -188,31 +624,14 @@ func processPodContainer(name string, appMeta helmify.AppMetadata, c corev1.Cont
 		return c, err
 	}
 
-	for _, e := range c.EnvFrom {
-		if e.SecretRef != nil {
-			e.SecretRef.Name = appMeta.TemplatedName(e.SecretRef.Name)
-		}
-		if e.ConfigMapRef != nil {
-			e.ConfigMapRef.Name = appMeta.TemplatedName(e.ConfigMapRef.Name)
-		}
+	c, err = processEnvFrom(name, appMeta, c, values)
+	if err != nil {
+		return c, err
 	}
 	c.Env = append(c.Env, corev1.EnvVar{
 		Name:  cluster.DomainEnv,
 		Value: fmt.Sprintf("{{ quote .Values.%s }}", cluster.DomainKey),
 	})
-	for k, v := range c.Resources.Requests {
-		err = unstructured.SetNestedField(*values, v.ToUnstructured(), name, containerName, "resources", "requests", k.String())
-		if err != nil {
-			return c, fmt.Errorf("%w: unable to set container resources value", err)
-		}
-	}
-	for k, v := range c.Resources.Limits {
-		err = unstructured.SetNestedField(*values, v.ToUnstructured(), name, containerName, "resources", "limits", k.String())
-		if err != nil {
-			return c, fmt.Errorf("%w: unable to set container resources value", err)
-		}
-	}
-
 	if c.ImagePullPolicy != "" {
 		err = unstructured.SetNestedField(*values, string(c.ImagePullPolicy), name, containerName, "imagePullPolicy")
 		if err != nil {
@@ -223,6 +642,40 @@ func processPodContainer(name string, appMeta helmify.AppMetadata, c corev1.Cont
 	return c, nil
 }
 
+// processEnvFrom templates names of chart ConfigMaps and Secrets referenced by envFrom.
+// Prefix of envFrom entry is moved to <name>.<container>.envFrom.<configMap|secret>.<ref>.prefix value keyed by
+// kind and name of referenced object, so a ConfigMap and a Secret with the same name get different values.
+func processEnvFrom(name string, appMeta helmify.AppMetadata, c corev1.Container, values *helmify.Values) (corev1.Container, error) {
+	containerName := strcase.ToLowerCamel(c.Name)
+	for i, e := range c.EnvFrom {
+		var refKind, refName string
+		if e.SecretRef != nil {
+			refKind, refName = "secret", e.SecretRef.Name
+			e.SecretRef.Name = appMeta.TemplatedName(e.SecretRef.Name)
+		}
+		if e.ConfigMapRef != nil {
+			refKind, refName = "configMap", e.ConfigMapRef.Name
+			e.ConfigMapRef.Name = appMeta.TemplatedName(e.ConfigMapRef.Name)
+		}
+		if refName != "" && appMeta.TemplatedName(refName) == refName {
+			logrus.WithFields(logrus.Fields{
+				"Workload":  name,
+				"Container": c.Name,
+				"Source":    refName,
+			}).Debug("envFrom source not in chart: name kept")
+		}
+		if e.Prefix == "" || refName == "" {
+			continue
+		}
+		prefix, err := values.Add(e.Prefix, name, containerName, "envFrom", refKind, appMeta.TrimName(refName), "prefix")
+		if err != nil {
+			return c, fmt.Errorf("%w: unable to set container envFrom prefix", err)
+		}
+		c.EnvFrom[i].Prefix = prefix
+	}
+	return c, nil
+}
+
 func processEnv(name string, appMeta helmify.AppMetadata, c corev1.Container, values *helmify.Values) (corev1.Container, error) {
 	containerName := strcase.ToLowerCamel(c.Name)
 	for i := 0; i < len(c.Env); i++ {
@@ -246,3 +699,68 @@ func processEnv(name string, appMeta helmify.AppMetadata, c corev1.Container, va
 	}
 	return c, nil
 }
+
+// ProcessTemplate templates pod template of workloads rendering pod labels, annotations and spec separately
+// (Deployment, DaemonSet). Returns templates of pod labels and annotations and templated pod spec.
+// Pod template is located in obj under spec.template. Pod values are merged into given values.
+// Indent is the indentation of pod labels and annotations content.
+func ProcessTemplate(objName string, appMeta helmify.AppMetadata, obj *unstructured.Unstructured, template corev1.PodTemplateSpec, selector map[string]string, values *helmify.Values, indent int) (string, string, map[string]interface{}, error) {
+	podLabels, err := ProcessPodLabels(objName, appMeta, template.ObjectMeta.Labels, selector, values, indent)
+	if err != nil {
+		return "", "", nil, err
+	}
+	podAnnotations, podSpecMap, err := processTemplate(objName, appMeta, obj, template, values, indent, "template")
+	if err != nil {
+		return "", "", nil, err
+	}
+	return podLabels, podAnnotations, podSpecMap, nil
+}
+
+// ProcessTemplateSpec templates pod template located in specMap under given path: pod labels, pod annotations and
+// pod spec. Pod labels are templated as in ProcessTemplate with the given workload selector and rendered by
+// RenderPlaceholders after spec is marshaled.
+// specMap is the templated spec of obj, so the pod template is located in obj under the same path in spec.
+// Pod values are merged into given values. Indent is the indentation of pod annotations content in the rendered spec.
+// Used by workloads rendering the whole spec from specMap (StatefulSet, Job, CronJob, ReplicationController).
+func ProcessTemplateSpec(objName string, appMeta helmify.AppMetadata, obj *unstructured.Unstructured, template corev1.PodTemplateSpec, selector map[string]string, specMap map[string]interface{}, values *helmify.Values, indent int, path ...string) error {
+	err := processPodLabelsPlaceholder(objName, appMeta, template.ObjectMeta.Labels, selector, specMap, values, path...)
+	if err != nil {
+		return err
+	}
+	podAnnotations, podSpecMap, err := processTemplate(objName, appMeta, obj, template, values, indent, path...)
+	if err != nil {
+		return err
+	}
+	err = unstructured.SetNestedField(specMap, podAnnotations, append(path, "metadata", "annotations")...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to template pod annotations", err)
+	}
+	err = unstructured.SetNestedMap(specMap, podSpecMap, append(path, "spec")...)
+	if err != nil {
+		return fmt.Errorf("%w: unable to template pod spec", err)
+	}
+	return nil
+}
+
+// processTemplate templates pod annotations and pod spec shared by all workloads. Pod template is located in obj
+// spec under given path.
+func processTemplate(objName string, appMeta helmify.AppMetadata, obj *unstructured.Unstructured, template corev1.PodTemplateSpec, values *helmify.Values, indent int, path ...string) (string, map[string]interface{}, error) {
+	podAnnotations, err := ProcessPodAnnotations(objName, appMeta, template.ObjectMeta.Annotations, values, indent)
+	if err != nil {
+		return "", nil, err
+	}
+	podSpecMap, podValues, err := ProcessSpec(objName, appMeta, template.Spec)
+	if err != nil {
+		return "", nil, err
+	}
+	rawPath := append(append([]string{"spec"}, path...), "spec")
+	err = processResizePolicy(objName, obj, podSpecMap, &podValues, rawPath...)
+	if err != nil {
+		return "", nil, err
+	}
+	err = values.Merge(podValues)
+	if err != nil {
+		return "", nil, err
+	}
+	return podAnnotations, podSpecMap, nil
+}
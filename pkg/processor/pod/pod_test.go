@@ -3,9 +3,13 @@ package pod
 import (
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/metadata"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/arttor/helmify/internal"
@@ -40,6 +44,38 @@ spec:
         - containerPort: 80
 `
 
+	strDeploymentWithPullSecrets = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      imagePullSecrets:
+      - name: nginx-regcred
+      - name: external-regcred
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+`
+
+	strRegistrySecret = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: nginx-regcred
+type: kubernetes.io/dockerconfigjson
+data:
+  .dockerconfigjson: e30=
+`
+
 	strDeploymentWithNoArgs = `
 apiVersion: apps/v1
 kind: Deployment
@@ -63,6 +99,164 @@ spec:
         ports:
         - containerPort: 80
 `
+
+	strPriorityClass = `
+apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: nginx-high
+value: 1000000
+`
+
+	strDeploymentWithPriorityClass = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      priorityClassName: nginx-high
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+`
+
+	strDeploymentWithTolerations = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: web
+        effect: NoSchedule
+      - operator: Exists
+        effect: NoExecute
+        tolerationSeconds: 300
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+`
+
+	strDeploymentWithStartupProbe = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        startupProbe:
+          httpGet:
+            path: /healthz
+            port: 8080
+          failureThreshold: 30
+      - name: sidecar
+        image: proxy:v1
+`
+
+	strDeploymentWithCSIVolume = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+      volumes:
+      - name: secrets-store
+        csi:
+          driver: secrets-store.csi.k8s.io
+          volumeAttributes:
+            secretProviderClass: nginx-dev
+          nodePublishSecretRef:
+            name: store-creds
+`
+
+	strDeploymentWithWorkingDir = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        workingDir: /usr/share/nginx
+      - name: sidecar
+        image: busybox:1.36
+`
+
+	strDeploymentWithTTY = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        stdin: true
+        tty: true
+      - name: sidecar
+        image: busybox:1.36
+`
+
+	strDeploymentWithEnvFromPrefix = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        envFrom:
+        - prefix: APP_
+          configMapRef:
+            name: nginx-config
+        - secretRef:
+            name: nginx-secret
+`
+
+	strDeploymentWithAffinity = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: nginx-deployment
+spec:
+  template:
+    spec:
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        resources:
+          limits:
+            cpu: 500m
+`
 )
 
 func Test_pod_Process(t *testing.T) {
@@ -83,8 +277,9 @@ func Test_pod_Process(t *testing.T) {
 							"value": "{{ quote .Values.kubernetesClusterDomain }}",
 						},
 					},
-					"image": "{{ .Values.nginx.nginx.image.repository }}:{{ .Values.nginx.nginx.image.tag | default .Chart.AppVersion }}",
-					"name":  "nginx", "ports": []interface{}{
+					"envFrom": "{{ .Values.nginx.extraEnvFrom | default list | toJson }}",
+					"image":   "{{ .Values.nginx.nginx.image.repository }}:{{ .Values.nginx.nginx.image.tag | default .Chart.AppVersion }}",
+					"name":    "nginx", "ports": []interface{}{
 						map[string]interface{}{
 							"containerPort": int64(80),
 						},
@@ -96,6 +291,7 @@ func Test_pod_Process(t *testing.T) {
 
 		assert.Equal(t, helmify.Values{
 			"nginx": map[string]interface{}{
+				"extraEnvFrom": []interface{}{},
 				"nginx": map[string]interface{}{
 					"image": map[string]interface{}{
 						"repository": "nginx",
@@ -126,8 +322,9 @@ func Test_pod_Process(t *testing.T) {
 							"value": "{{ quote .Values.kubernetesClusterDomain }}",
 						},
 					},
-					"image": "{{ .Values.nginx.nginx.image.repository }}:{{ .Values.nginx.nginx.image.tag | default .Chart.AppVersion }}",
-					"name":  "nginx", "ports": []interface{}{
+					"envFrom": "{{ .Values.nginx.extraEnvFrom | default list | toJson }}",
+					"image":   "{{ .Values.nginx.nginx.image.repository }}:{{ .Values.nginx.nginx.image.tag | default .Chart.AppVersion }}",
+					"name":    "nginx", "ports": []interface{}{
 						map[string]interface{}{
 							"containerPort": int64(80),
 						},
@@ -139,6 +336,7 @@ func Test_pod_Process(t *testing.T) {
 
 		assert.Equal(t, helmify.Values{
 			"nginx": map[string]interface{}{
+				"extraEnvFrom": []interface{}{},
 				"nginx": map[string]interface{}{
 					"image": map[string]interface{}{
 						"repository": "nginx",
@@ -149,4 +347,239 @@ func Test_pod_Process(t *testing.T) {
 		}, tmpl)
 	})
 
+	t.Run("in-chart image pull secret", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithPullSecrets)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(strRegistrySecret))
+		appMeta.Load(obj)
+
+		specMap, _, err := ProcessSpec("nginx", appMeta, deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"name": `{{ include "chart.fullname" . }}-regcred`},
+			map[string]interface{}{"name": "external-regcred"},
+		}, specMap["imagePullSecrets"])
+	})
+	t.Run("affinity and resources", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithAffinity)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "{{- toYaml .Values.nginx.affinity | nindent 8 }}", specMap["affinity"])
+		assert.Equal(t, map[string]interface{}{
+			"podAntiAffinity": map[string]interface{}{
+				"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+					map[string]interface{}{
+						"weight":          int64(100),
+						"podAffinityTerm": map[string]interface{}{"topologyKey": "kubernetes.io/hostname"},
+					},
+				},
+			},
+		}, values["nginx"].(map[string]interface{})["affinity"])
+
+		container := specMap["containers"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, `{{- include "chart.resources" .Values.nginx.nginx.resources | nindent 10 }}`, container["resources"])
+		assert.Equal(t, map[string]interface{}{
+			"limits": map[string]interface{}{"cpu": "500m"},
+		}, values["nginx"].(map[string]interface{})["nginx"].(map[string]interface{})["resources"])
+	})
+	t.Run("in-chart priority class", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithPriorityClass)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(strPriorityClass))
+		appMeta.Load(obj)
+
+		specMap, _, err := ProcessSpec("nginx", appMeta, deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+		assert.Equal(t, `{{ include "chart.fullname" . }}-high`, specMap["priorityClassName"])
+
+		deploy.Spec.Template.Spec.PriorityClassName = "system-cluster-critical"
+		specMap, _, err = ProcessSpec("nginx", appMeta, deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+		assert.Equal(t, "system-cluster-critical", specMap["priorityClassName"])
+	})
+	t.Run("tolerations", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithTolerations)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+		assert.Equal(t, "{{- toYaml .Values.nginx.tolerations | nindent 8 }}", specMap["tolerations"])
+		assert.Len(t, values["nginx"].(map[string]interface{})["tolerations"], 2)
+
+		specMap, values, err = ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart", TolerationFields: true}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"key":      "{{ .Values.nginx.tolerations.toleration.key | quote }}",
+				"operator": "{{ .Values.nginx.tolerations.toleration.operator | quote }}",
+				"value":    "{{ .Values.nginx.tolerations.toleration.value | quote }}",
+				"effect":   "{{ .Values.nginx.tolerations.toleration.effect | quote }}",
+			},
+			map[string]interface{}{
+				"operator":          "{{ .Values.nginx.tolerations.toleration1.operator | quote }}",
+				"effect":            "{{ .Values.nginx.tolerations.toleration1.effect | quote }}",
+				"tolerationSeconds": "{{ .Values.nginx.tolerations.toleration1.tolerationSeconds }}",
+			},
+		}, specMap["tolerations"])
+		assert.Equal(t, map[string]interface{}{
+			"toleration":  map[string]interface{}{"key": "dedicated", "operator": "Equal", "value": "web", "effect": "NoSchedule"},
+			"toleration1": map[string]interface{}{"operator": "Exists", "effect": "NoExecute", "tolerationSeconds": int64(300)},
+		}, values["nginx"].(map[string]interface{})["tolerations"])
+	})
+	t.Run("startup probe budget", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithStartupProbe)
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy)
+		assert.NoError(t, err)
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		probe := specMap["containers"].([]interface{})[0].(map[string]interface{})["startupProbe"].(map[string]interface{})
+		assert.Equal(t, "{{ .Values.nginx.nginx.startupProbe.failureThreshold }}", probe["failureThreshold"])
+		assert.Equal(t, "{{ .Values.nginx.nginx.startupProbe.periodSeconds }}", probe["periodSeconds"])
+		assert.Equal(t, map[string]interface{}{"path": "/healthz", "port": int64(8080)}, probe["httpGet"])
+		assert.Equal(t, map[string]interface{}{
+			"failureThreshold": int64(30),
+			"periodSeconds":    int64(10),
+		}, values["nginx"].(map[string]interface{})["nginx"].(map[string]interface{})["startupProbe"])
+		assert.NotContains(t, values["nginx"].(map[string]interface{})["sidecar"], "startupProbe")
+	})
+	t.Run("csi volume", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithCSIVolume)
+		assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		csi := specMap["volumes"].([]interface{})[0].(map[string]interface{})["csi"].(map[string]interface{})
+		assert.Equal(t, "secrets-store.csi.k8s.io", csi["driver"])
+		assert.Equal(t, map[string]interface{}{
+			"secretProviderClass": "{{ .Values.nginx.volumes.secretsStore.volumeAttributes.secretProviderClass | quote }}",
+		}, csi["volumeAttributes"])
+		assert.Equal(t, map[string]interface{}{
+			"name": "{{ .Values.nginx.volumes.secretsStore.nodePublishSecretRef | quote }}",
+		}, csi["nodePublishSecretRef"])
+		assert.Equal(t, map[string]interface{}{
+			"volumeAttributes":     map[string]interface{}{"secretProviderClass": "nginx-dev"},
+			"nodePublishSecretRef": "store-creds",
+		}, values["nginx"].(map[string]interface{})["volumes"].(map[string]interface{})["secretsStore"])
+	})
+	t.Run("working dir", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithWorkingDir)
+		assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		containers := specMap["containers"].([]interface{})
+		assert.Equal(t, "{{ .Values.nginx.nginx.workingDir | quote }}", containers[0].(map[string]interface{})["workingDir"])
+		assert.Equal(t, "/usr/share/nginx", values["nginx"].(map[string]interface{})["nginx"].(map[string]interface{})["workingDir"])
+		assert.NotContains(t, containers[1], "workingDir")
+		assert.NotContains(t, values["nginx"].(map[string]interface{})["sidecar"], "workingDir")
+	})
+	t.Run("debug fields", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithTTY)
+		assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		containers := specMap["containers"].([]interface{})
+		assert.Equal(t, "{{ .Values.nginx.nginx.tty }}", containers[0].(map[string]interface{})["tty"])
+		assert.Equal(t, "{{ .Values.nginx.nginx.stdin }}", containers[0].(map[string]interface{})["stdin"])
+		assert.NotContains(t, containers[0], "stdinOnce")
+		assert.NotContains(t, containers[1], "tty")
+		nginx := values["nginx"].(map[string]interface{})["nginx"].(map[string]interface{})
+		assert.Equal(t, true, nginx["tty"])
+		assert.Equal(t, true, nginx["stdin"])
+		assert.NotContains(t, nginx, "stdinOnce")
+	})
+	t.Run("envFrom prefix", func(t *testing.T) {
+		var deploy appsv1.Deployment
+		obj := internal.GenerateObj(strDeploymentWithEnvFromPrefix)
+		assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), deploy.Spec.Template.Spec)
+		assert.NoError(t, err)
+
+		assert.Equal(t, "[{configMapRef: {name: nginx-config}, prefix: {{ .Values.nginx.nginx.envFrom.configMap.nginxConfig.prefix | quote }}}, "+
+			"{secretRef: {name: nginx-secret}}{{- range .Values.nginx.extraEnvFrom }}, {{ toJson . }}{{- end }}]",
+			specMap["containers"].([]interface{})[0].(map[string]interface{})["envFrom"])
+		assert.Equal(t, map[string]interface{}{
+			"configMap": map[string]interface{}{"nginxConfig": map[string]interface{}{"prefix": "APP_"}},
+		}, values["nginx"].(map[string]interface{})["nginx"].(map[string]interface{})["envFrom"])
+	})
+	t.Run("arch node selector", func(t *testing.T) {
+		podSpec := func() corev1.PodSpec {
+			var deploy appsv1.Deployment
+			obj := internal.GenerateObj(strDeployment)
+			assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+			return deploy.Spec.Template.Spec
+		}
+
+		specMap, values, err := ProcessSpec("nginx", metadata.New(config.Config{ChartName: "chart"}), podSpec())
+		assert.NoError(t, err)
+		assert.NotContains(t, specMap, "nodeSelector")
+		assert.NotContains(t, values["nginx"], "nodeSelector")
+
+		appMeta := metadata.New(config.Config{ChartName: "chart", ImageArch: map[string]string{"nginx": "arm64"}})
+		specMap, values, err = ProcessSpec("nginx", appMeta, podSpec())
+		assert.NoError(t, err)
+		assert.Equal(t, "{{- toYaml .Values.nginx.nodeSelector | nindent 8 }}", specMap["nodeSelector"])
+		assert.Equal(t, map[string]interface{}{"kubernetes.io/arch": "arm64"}, values["nginx"].(map[string]interface{})["nodeSelector"])
+
+		appMeta = metadata.New(config.Config{ChartName: "chart", ImageArch: map[string]string{"busybox": "arm64"}})
+		specMap, _, err = ProcessSpec("nginx", appMeta, podSpec())
+		assert.NoError(t, err)
+		assert.NotContains(t, specMap, "nodeSelector")
+	})
+}
+
+func Test_ProcessTemplate(t *testing.T) {
+	var deploy appsv1.Deployment
+	obj := internal.GenerateObj(strDeployment)
+	assert.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy))
+	deploy.Spec.Template.Labels = map[string]string{"app": "nginx", "tier": "web"}
+	appMeta := metadata.New(config.Config{ChartName: "chart"})
+
+	template := deploy.Spec.Template.DeepCopy()
+	values := helmify.Values{}
+	labels, annotations, specMap, err := ProcessTemplate("nginx", appMeta, obj, deploy.Spec.Template, map[string]string{"app": "nginx"}, &values, 8)
+	assert.NoError(t, err)
+	assert.Contains(t, labels, "app: nginx")
+	assert.Equal(t, map[string]interface{}{"tier": "web"}, values["nginx"].(map[string]interface{})["podLabels"])
+
+	// pod template is templated the same way as for workloads rendering the whole spec
+	templateValues := helmify.Values{}
+	templateSpecMap := map[string]interface{}{}
+	err = ProcessTemplateSpec("nginx", appMeta, obj, *template, map[string]string{"app": "nginx"}, templateSpecMap, &templateValues, 8, "template")
+	assert.NoError(t, err)
+	templateLabels, _, _ := unstructured.NestedMap(templateSpecMap, "template", "metadata", "labels")
+	assert.Equal(t, map[string]interface{}{"app": "nginx", podLabelsPlaceholder: "nginx chart app"}, templateLabels)
+	labelsYaml, err := yamlformat.Marshal(templateLabels, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, labels, RenderPlaceholders(labelsYaml))
+	assert.Equal(t, map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations, "labels": templateLabels},
+		"spec":     specMap,
+	}, templateSpecMap["template"])
+	assert.Equal(t, templateValues, values)
+}
+
+func Test_imageRepository(t *testing.T) {
+	assert.Equal(t, "nginx", imageRepository("nginx:1.25"))
+	assert.Equal(t, "registry:5000/team/app", imageRepository("registry:5000/team/app:v1"))
+	assert.Equal(t, "registry:5000/team/app", imageRepository("registry:5000/team/app"))
+	assert.Equal(t, "ghcr.io/app", imageRepository("ghcr.io/app:v1@sha256:abc"))
 }
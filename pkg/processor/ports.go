@@ -0,0 +1,90 @@
+package processor
+
+import (
+	"fmt"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PortNamesKey - values key for named ports shared between chart objects.
+const PortNamesKey = "portNames"
+
+// TemplatePortName - converts port name shared between chart objects to its helm template {{ .Values.portNames.<name> }}
+// and adds the name to values. Single value keeps all objects referencing the port consistent.
+// Returns name as it is if the port is not shared.
+func TemplatePortName(appMeta helmify.AppMetadata, values helmify.Values, name string) (string, error) {
+	if name == "" || !appMeta.IsSharedPortName(name) {
+		return name, nil
+	}
+	valueName := strcase.ToLowerCamel(name)
+	err := unstructured.SetNestedField(values, name, PortNamesKey, valueName)
+	if err != nil {
+		return "", fmt.Errorf("%w: unable to set port name value: %s", err, name)
+	}
+	return fmt.Sprintf("{{ .Values.%s.%s }}", PortNamesKey, valueName), nil
+}
+
+// TemplateContainerPorts - templates shared names of container ports and probe ports in the list of containers.
+func TemplateContainerPorts(appMeta helmify.AppMetadata, values helmify.Values, containers []interface{}) error {
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		ports, _, _ := unstructured.NestedSlice(container, "ports")
+		if err := TemplatePortFields(appMeta, values, ports, "name"); err != nil {
+			return err
+		}
+		if len(ports) != 0 {
+			if err := unstructured.SetNestedSlice(container, ports, "ports"); err != nil {
+				return err
+			}
+		}
+		for _, probe := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+			for _, handler := range []string{"httpGet", "tcpSocket"} {
+				h, ok, _ := unstructured.NestedMap(container, probe, handler)
+				if !ok {
+					continue
+				}
+				if err := templatePortField(appMeta, values, h, "port"); err != nil {
+					return err
+				}
+				if err := unstructured.SetNestedMap(container, h, probe, handler); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// TemplatePortFields - templates shared port names stored under given fields of each port definition in the list.
+func TemplatePortFields(appMeta helmify.AppMetadata, values helmify.Values, ports []interface{}, fields ...string) error {
+	for _, p := range ports {
+		port, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, field := range fields {
+			if err := templatePortField(appMeta, values, port, field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func templatePortField(appMeta helmify.AppMetadata, values helmify.Values, port map[string]interface{}, field string) error {
+	name, isStr := port[field].(string)
+	if !isStr {
+		return nil
+	}
+	templated, err := TemplatePortName(appMeta, values, name)
+	if err != nil {
+		return err
+	}
+	port[field] = templated
+	return nil
+}
@@ -0,0 +1,69 @@
+package quota
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var limitRangeGVC = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "LimitRange",
+}
+
+// NewLimitRange creates processor for k8s LimitRange resource.
+func NewLimitRange() helmify.Processor {
+	return &limitRange{}
+}
+
+type limitRange struct{}
+
+// Process k8s LimitRange object into template. Returns false if not capable of processing given resource type.
+func (l limitRange) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind() != limitRangeGVC {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	specMap, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get limit range spec", err)
+	}
+
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	values := helmify.Values{}
+	tpl, err := processor.ExternalizeBlock(values, specMap, nameCamel+".limits", 4, "limits")
+	if err != nil {
+		return true, nil, err
+	}
+	if tpl != "" {
+		specMap["limits"] = tpl
+	}
+
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+
+	return true, &result{
+		name: name,
+		tmpl: quotaTempl,
+		data: struct {
+			Meta string
+			Spec string
+		}{Meta: meta, Spec: spec},
+		values: values,
+	}, nil
+}
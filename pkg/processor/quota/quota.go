@@ -0,0 +1,132 @@
+package quota
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var quotaTempl, _ = template.New("quota").Parse(
+	`{{ .Meta }}
+{{ .Spec }}`)
+
+var quotaGVC = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "ResourceQuota",
+}
+
+// New creates processor for k8s ResourceQuota resource.
+func New() helmify.Processor {
+	return &quota{}
+}
+
+type quota struct{}
+
+// Process k8s ResourceQuota object into template. Returns false if not capable of processing given resource type.
+func (q quota) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind() != quotaGVC {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	specMap, _, err := unstructured.NestedMap(obj.Object, "spec")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to get resource quota spec", err)
+	}
+
+	name := appMeta.TrimName(obj.GetName())
+	nameCamel := strcase.ToLowerCamel(name)
+
+	values := helmify.Values{}
+	hard, err := processor.ExternalizeBlock(values, specMap, nameCamel+".hard", 4, "hard")
+	if err != nil {
+		return true, nil, err
+	}
+	if hard != "" {
+		specMap["hard"] = hard
+	}
+	if err = processScopeSelector(appMeta, specMap); err != nil {
+		return true, nil, err
+	}
+
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+
+	return true, &result{
+		name: name,
+		tmpl: quotaTempl,
+		data: struct {
+			Meta string
+			Spec string
+		}{Meta: meta, Spec: spec},
+		values: values,
+	}, nil
+}
+
+// processScopeSelector templates names of chart PriorityClasses in PriorityClass scope selector expressions.
+// Scopes and other scope selector expressions are kept as they are.
+func processScopeSelector(appMeta helmify.AppMetadata, specMap map[string]interface{}) error {
+	expressions, ok, err := unstructured.NestedSlice(specMap, "scopeSelector", "matchExpressions")
+	if err != nil {
+		return fmt.Errorf("%w: unable to get resource quota scope selector", err)
+	}
+	if !ok {
+		return nil
+	}
+	for _, e := range expressions {
+		expr, ok := e.(map[string]interface{})
+		if !ok || expr["scopeName"] != string(corev1.ResourceQuotaScopePriorityClass) {
+			continue
+		}
+		classes, _, _ := unstructured.NestedStringSlice(expr, "values")
+		templated := make([]interface{}, len(classes))
+		for i, c := range classes {
+			templated[i] = c
+			// cluster-wide priority classes like system-cluster-critical are kept as they are
+			if appMeta.HasObject("PriorityClass", c) {
+				templated[i] = appMeta.TemplatedName(c)
+			}
+		}
+		if len(templated) != 0 {
+			expr["values"] = templated
+		}
+	}
+	return unstructured.SetNestedSlice(specMap, expressions, "scopeSelector", "matchExpressions")
+}
+
+type result struct {
+	name string
+	tmpl *template.Template
+	data struct {
+		Meta string
+		Spec string
+	}
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return r.name + ".yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	return r.tmpl.Execute(writer, r.data)
+}
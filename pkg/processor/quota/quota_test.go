@@ -0,0 +1,110 @@
+package quota
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	quotaYaml = `apiVersion: v1
+kind: ResourceQuota
+metadata:
+  name: my-app-high
+spec:
+  hard:
+    pods: "10"
+    requests.cpu: "4"
+  scopeSelector:
+    matchExpressions:
+    - operator: In
+      scopeName: PriorityClass
+      values:
+      - my-app-high
+      - system-cluster-critical`
+
+	priorityClassYaml = `apiVersion: scheduling.k8s.io/v1
+kind: PriorityClass
+metadata:
+  name: my-app-high
+value: 1000000`
+
+	limitRangeYaml = `apiVersion: v1
+kind: LimitRange
+metadata:
+  name: my-app-limits
+spec:
+  limits:
+  - type: Container
+    default:
+      cpu: 500m`
+)
+
+func Test_quota_Process(t *testing.T) {
+	var testInstance quota
+
+	newMeta := func(objYaml ...string) helmify.AppMetadata {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		for _, y := range objYaml {
+			appMeta.Load(internal.GenerateObj(y))
+		}
+		return appMeta
+	}
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(quotaYaml)
+		processed, _, err := testInstance.Process(newMeta(quotaYaml), obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("priority class scope", func(t *testing.T) {
+		obj := internal.GenerateObj(quotaYaml)
+		_, tmpl, err := testInstance.Process(newMeta(priorityClassYaml, quotaYaml), obj)
+		assert.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "hard: {{- toYaml .Values.myAppHigh.hard | nindent 4 }}")
+		assert.Contains(t, buf.String(), `      values:
+      - {{ include "chart.fullname" . }}-my-app-high
+      - system-cluster-critical`)
+		assert.Equal(t, map[string]interface{}{"pods": "10", "requests.cpu": "4"},
+			tmpl.Values()["myAppHigh"].(map[string]interface{})["hard"])
+	})
+	t.Run("priority class not in chart", func(t *testing.T) {
+		obj := internal.GenerateObj(quotaYaml)
+		_, tmpl, err := testInstance.Process(newMeta(quotaYaml), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "      - my-app-high\n      - system-cluster-critical")
+	})
+}
+
+func Test_limitRange_Process(t *testing.T) {
+	var testInstance limitRange
+
+	obj := internal.GenerateObj(limitRangeYaml)
+	appMeta := metadata.New(config.Config{ChartName: "chart"})
+	appMeta.Load(obj)
+	processed, tmpl, err := testInstance.Process(appMeta, obj)
+	assert.NoError(t, err)
+	assert.True(t, processed)
+
+	buf := bytes.Buffer{}
+	assert.NoError(t, tmpl.Write(&buf))
+	assert.Contains(t, buf.String(), "limits: {{- toYaml .Values.myAppLimits.limits | nindent 4 }}")
+	assert.Equal(t, "my-app-limits.yaml", tmpl.Filename())
+	assert.Len(t, tmpl.Values()["myAppLimits"].(map[string]interface{})["limits"], 1)
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/arttor/helmify/pkg/helmify"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
@@ -21,6 +22,9 @@ var roleTempl, _ = template.New("clusterRole").Parse(
 {{- end}}
 {{ .Rules }}`)
 
+const rulesTempl = `rules:
+{{- toYaml .Values.%[1]s.%[2]s.rules | nindent 0 }}`
+
 var clusterRoleGVC = schema.GroupVersionKind{
 	Group:   "rbac.authorization.k8s.io",
 	Version: "v1",
@@ -67,9 +71,28 @@ func (r role) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 		}
 	}
 
-	rules, err := yamlformat.Marshal(map[string]interface{}{"rules": obj.Object["rules"]}, 0)
-	if err != nil {
-		return true, nil, err
+	values := helmify.Values{}
+	var rules string
+	if appMeta.Config().RBACRulesValues {
+		name := strcase.ToLowerCamel(appMeta.TrimName(obj.GetName()))
+		kind := strcase.ToLowerCamel(obj.GetKind())
+		objRules, _, err := unstructured.NestedSlice(obj.Object, "rules")
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable to get %s rules", err, obj.GetKind())
+		}
+		if objRules == nil {
+			objRules = []interface{}{}
+		}
+		err = unstructured.SetNestedSlice(values, objRules, name, kind, "rules")
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable to set %s rules value", err, obj.GetKind())
+		}
+		rules = fmt.Sprintf(rulesTempl, name, kind)
+	} else {
+		rules, err = yamlformat.Marshal(map[string]interface{}{"rules": obj.Object["rules"]}, 0)
+		if err != nil {
+			return true, nil, err
+		}
 	}
 
 	return true, &crResult{
@@ -79,6 +102,7 @@ func (r role) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 			AggregationRule string
 			Rules           string
 		}{Meta: meta, AggregationRule: aggregationRule, Rules: rules},
+		values: values,
 	}, nil
 }
 
@@ -89,6 +113,7 @@ type crResult struct {
 		AggregationRule string
 		Rules           string
 	}
+	values helmify.Values
 }
 
 func (r *crResult) Filename() string {
@@ -100,7 +125,7 @@ func (r *crResult) GVK() schema.GroupVersionKind {
 }
 
 func (r *crResult) Values() helmify.Values {
-	return helmify.Values{}
+	return r.values
 }
 
 func (r *crResult) Write(writer io.Writer) error {
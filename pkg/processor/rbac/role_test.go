@@ -1,8 +1,14 @@
 package rbac
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/helmify"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -37,6 +43,48 @@ func Test_clusterRole_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, true, processed)
 	})
+	t.Run("rules in values", func(t *testing.T) {
+		obj := internal.GenerateObj(clusterRoleYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart", RBACRulesValues: true})
+		appMeta.Load(obj)
+		appMeta.Load(internal.GenerateObj(serviceAccountYaml))
+		processed, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+
+		rules := tmpl.Values()["managerRole"].(map[string]interface{})["clusterRole"].(map[string]interface{})["rules"]
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{
+				"apiGroups": []interface{}{""},
+				"resources": []interface{}{"pods"},
+				"verbs":     []interface{}{"get", "list"},
+			},
+		}, rules)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `name: {{ include "chart.fullname" . }}-manager-role`)
+		assert.Contains(t, buf.String(), "key: my.operator.dev/release")
+		assert.Contains(t, buf.String(), "rules:\n{{- toYaml .Values.managerRole.clusterRole.rules | nindent 0 }}")
+	})
+	t.Run("role and cluster role rules of same name", func(t *testing.T) {
+		clusterRole := internal.GenerateObj(clusterRoleYaml)
+		role := internal.GenerateObj(strings.Replace(strings.Replace(clusterRoleYaml, "kind: ClusterRole", "kind: Role", 1),
+			"aggregationRule:", "aggregationRuleRemoved:", 1))
+		role.SetNamespace("my-operator-system")
+		appMeta := metadata.New(config.Config{ChartName: "chart", RBACRulesValues: true})
+		appMeta.Load(clusterRole)
+		appMeta.Load(role)
+
+		values := helmify.Values{}
+		for _, obj := range []*unstructured.Unstructured{clusterRole, role} {
+			_, tmpl, err := testInstance.Process(appMeta, obj)
+			assert.NoError(t, err)
+			assert.NoError(t, values.Merge(tmpl.Values()))
+		}
+		assert.Contains(t, values["myOperatorManagerRole"], "clusterRole")
+		assert.Contains(t, values["myOperatorManagerRole"], "role")
+	})
 	t.Run("skipped", func(t *testing.T) {
 		obj := internal.TestNs
 		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
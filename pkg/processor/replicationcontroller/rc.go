@@ -0,0 +1,154 @@
+package replicationcontroller
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	"github.com/arttor/helmify/pkg/processor/deployment"
+	"github.com/arttor/helmify/pkg/processor/pod"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var rcGVC = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "ReplicationController",
+}
+
+var rcTempl, _ = template.New("replicationController").Parse(
+	`{{- .Meta }}
+spec:
+{{ .Spec }}`)
+
+// New creates processor for legacy k8s ReplicationController resource.
+// ReplicationController is templated as it is or converted to Deployment if enabled in config.
+func New() helmify.Processor {
+	return &rc{}
+}
+
+type rc struct{}
+
+// Process k8s ReplicationController object into template. Returns false if not capable of processing given resource type.
+func (r rc) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind() != rcGVC {
+		return false, nil, nil
+	}
+	if appMeta.Config().ConvertReplicationControllers {
+		depl, err := toDeployment(obj)
+		if err != nil {
+			return true, nil, err
+		}
+		logrus.WithField("ReplicationController", obj.GetName()).Debug("converted to Deployment")
+		_, res, err := deployment.New().Process(appMeta, depl)
+		return true, res, err
+	}
+
+	controller := corev1.ReplicationController{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &controller)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to cast to ReplicationController", err)
+	}
+	if controller.Spec.Template == nil {
+		return true, nil, fmt.Errorf("no pod template in ReplicationController %s", obj.GetName())
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+
+	rcSpec := controller.Spec
+	rcSpecMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&rcSpec)
+	if err != nil {
+		return true, nil, err
+	}
+	delete((rcSpecMap["template"].(map[string]interface{}))["metadata"].(map[string]interface{}), "creationTimestamp")
+
+	values := helmify.Values{}
+	nameCamel := strcase.ToLowerCamel(appMeta.TrimName(obj.GetName()))
+
+	if rcSpec.Replicas != nil {
+		repl, err := values.Add(*rcSpec.Replicas, nameCamel, "replicas")
+		if err != nil {
+			return true, nil, err
+		}
+		rcSpecMap["replicas"] = repl
+	}
+
+	// selector defaults to pod template labels
+	selector := rcSpec.Selector
+	if len(selector) == 0 {
+		selector = rcSpec.Template.Labels
+	}
+	err = pod.ProcessTemplateSpec(nameCamel, appMeta, obj, *rcSpec.Template, selector, rcSpecMap, &values, 8, "template")
+	if err != nil {
+		return true, nil, err
+	}
+
+	spec, err := yamlformat.Marshal(rcSpecMap, 2)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.RenderPlaceholders(spec)
+
+	return true, &result{
+		values: values,
+		data: struct {
+			Meta string
+			Spec string
+		}{Meta: meta, Spec: spec},
+	}, nil
+}
+
+// toDeployment converts ReplicationController to apps/v1 Deployment with equality based selector.
+func toDeployment(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	depl := obj.DeepCopy()
+	depl.SetAPIVersion("apps/v1")
+	depl.SetKind("Deployment")
+	selector, _, err := unstructured.NestedStringMap(depl.Object, "spec", "selector")
+	if err != nil {
+		return nil, fmt.Errorf("%w: unable to get ReplicationController selector", err)
+	}
+	if len(selector) == 0 {
+		// selector defaults to pod template labels
+		selector, _, err = unstructured.NestedStringMap(depl.Object, "spec", "template", "metadata", "labels")
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to get ReplicationController pod labels", err)
+		}
+	}
+	err = unstructured.SetNestedStringMap(depl.Object, selector, "spec", "selector", "matchLabels")
+	if err != nil {
+		return nil, err
+	}
+	return depl, nil
+}
+
+type result struct {
+	data struct {
+		Meta string
+		Spec string
+	}
+	values helmify.Values
+}
+
+func (r *result) Filename() string {
+	return "replicationcontroller.yaml"
+}
+
+func (r *result) Values() helmify.Values {
+	return r.values
+}
+
+func (r *result) Write(writer io.Writer) error {
+	return rcTempl.Execute(writer, r.data)
+}
@@ -0,0 +1,70 @@
+package replicationcontroller
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const rcYaml = `apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: my-app-nginx
+spec:
+  replicas: 3
+  selector:
+    app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.14.2
+        ports:
+        - containerPort: 80`
+
+func Test_rc_Process(t *testing.T) {
+	var testInstance rc
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(rcYaml)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("templated in place", func(t *testing.T) {
+		obj := internal.GenerateObj(rcYaml)
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart"}), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "apiVersion: v1\nkind: ReplicationController")
+		assert.Contains(t, buf.String(), "replicas: {{ .Values.myAppNginx.replicas }}")
+		assert.Contains(t, buf.String(), "image: {{ .Values.myAppNginx.nginx.image.repository }}")
+		assert.Equal(t, "replicationcontroller.yaml", tmpl.Filename())
+		assert.Equal(t, int64(3), tmpl.Values()["myAppNginx"].(map[string]interface{})["replicas"])
+	})
+	t.Run("converted to deployment", func(t *testing.T) {
+		obj := internal.GenerateObj(rcYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart", ConvertReplicationControllers: true})
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "apiVersion: apps/v1\nkind: Deployment")
+		assert.Contains(t, buf.String(), "  selector:\n    matchLabels:\n      app: nginx")
+		assert.Contains(t, buf.String(), "image: {{ .Values.myAppNginx.nginx.image.repository }}")
+	})
+}
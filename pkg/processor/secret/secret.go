@@ -12,6 +12,7 @@ import (
 	"github.com/arttor/helmify/pkg/helmify"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -53,6 +54,10 @@ func (d secret) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructu
 	if err != nil {
 		return true, nil, fmt.Errorf("%w: unable to cast to secret", err)
 	}
+	if sec.Type == corev1.SecretTypeServiceAccountToken {
+		logrus.WithField("Secret", obj.GetName()).Debug("service account token data populated by cluster: removed")
+		processServiceAccountToken(appMeta, obj, &sec)
+	}
 	meta, err := processor.ProcessObjMeta(appMeta, obj)
 	if err != nil {
 		return true, nil, err
@@ -125,6 +130,19 @@ func (d secret) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructu
 	}, nil
 }
 
+// processServiceAccountToken templates service account name of service account token Secret and drops its data.
+// Token, CA and namespace data as well as service account uid annotation are populated by the cluster.
+func processServiceAccountToken(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, sec *corev1.Secret) {
+	annotations := obj.GetAnnotations()
+	if saName, ok := annotations[corev1.ServiceAccountNameKey]; ok {
+		annotations[corev1.ServiceAccountNameKey] = appMeta.TemplatedName(saName)
+	}
+	delete(annotations, corev1.ServiceAccountUIDKey)
+	obj.SetAnnotations(annotations)
+	sec.Data = nil
+	sec.StringData = nil
+}
+
 type result struct {
 	name string
 	data struct {
@@ -1,8 +1,10 @@
 package secret
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -21,6 +23,26 @@ metadata:
   namespace: my-operator-system
 type: opaque`
 
+const saTokenSecretYaml = `apiVersion: v1
+kind: Secret
+metadata:
+  name: my-operator-token
+  namespace: my-operator-system
+  annotations:
+    kubernetes.io/service-account.name: my-operator-builder
+    kubernetes.io/service-account.uid: 9b5c0e1e-8d43-4c8e-a7a0-4d7c58b4c3f1
+type: kubernetes.io/service-account-token
+data:
+  ca.crt: Y2E=
+  namespace: bXktb3BlcmF0b3Itc3lzdGVt
+  token: dG9rZW4=`
+
+const saYaml = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: my-operator-builder
+  namespace: my-operator-system`
+
 func Test_secret_Process(t *testing.T) {
 	var testInstance secret
 
@@ -36,4 +58,19 @@ func Test_secret_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
+	t.Run("service account token", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(saYaml))
+		appMeta.Load(internal.GenerateObj(saTokenSecretYaml))
+		_, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(saTokenSecretYaml))
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `kubernetes.io/service-account.name: '{{ include "chart.fullname" . }}-builder'`)
+		assert.Contains(t, buf.String(), "type: kubernetes.io/service-account-token")
+		assert.NotContains(t, buf.String(), "service-account.uid")
+		assert.NotContains(t, buf.String(), "\ndata:")
+		assert.NotContains(t, buf.String(), "token:")
+		assert.Empty(t, tmpl.Values())
+	})
 }
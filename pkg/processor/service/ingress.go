@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"github.com/arttor/helmify/pkg/helmify"
 	"github.com/arttor/helmify/pkg/processor"
+	"github.com/arttor/helmify/pkg/warning"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 	"io"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"strconv"
 	"strings"
 	"text/template"
 )
@@ -21,6 +25,26 @@ var ingressTempl, _ = template.New("ingress").Parse(
 {{ .Spec }}
 {{ .End }}`)
 
+// backendPortTempl renders IntOrString port value as service backend port 'name' or 'number'.
+const backendPortTempl = `{{ include "%[1]s.ingressBackendPort" .Values.%[2]s.ingress.backends.%[3]s.port }}`
+
+// sharedBackendPortTempl renders backend port name shared with other chart objects from portNames values.
+const sharedBackendPortTempl = `{{ include "%[1]s.ingressBackendPort" .Values.%[2]s.%[3]s }}`
+
+// hostTempl renders ingress host from subdomain and shared domain values.
+const hostTempl = `{{ printf "%%s.%%s" .Values.%[1]s.ingress.%[2]s .Values.global.domain }}`
+
+const backendPortPlaceholder = "helmifyIngressBackendPort%d"
+
+const hostPlaceholder = "helmifyIngressHost(%s)"
+
+const classNamePlaceholder = "helmifyIngressClassName"
+
+// classNameTempl omits ingressClassName when class is not set to let the cluster default IngressClass apply.
+const classNameTempl = `  {{- with .Values.%s.ingress.className }}
+  ingressClassName: {{ . }}
+  {{- end }}`
+
 var ingressGVC = schema.GroupVersionKind{
 	Group:   "networking.k8s.io",
 	Version: "v1",
@@ -46,7 +70,11 @@ func (r ingress) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstruct
 	}
 
 	av := helmify.Values{}
-	meta, err := processor.ProcessObjMeta(appMeta, obj, processor.WithAnnotations(av))
+	annotationsOpt := processor.WithAnnotations(av)
+	if appMeta.Config().IngressExtraAnnotations {
+		annotationsOpt = processor.WithExtraAnnotations(av)
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj, annotationsOpt)
 	if err != nil {
 		return true, nil, err
 	}
@@ -54,23 +82,54 @@ func (r ingress) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstruct
 	shortName := strings.TrimPrefix(name, "controller-manager-")
 	shortNameCamel := strcase.ToLowerCamel(shortName)
 
-	processIngressSpec(appMeta, &ing.Spec)
+	// annotation values set by ProcessObjMeta, including extraAnnotations
+	values := av
+
+	backendPorts, err := processIngressBackendPorts(shortNameCamel, obj.GetName(), appMeta, &ing.Spec, values)
+	if err != nil {
+		return true, nil, err
+	}
 
-	values := helmify.Values{}
+	if err = processIngressSpec(appMeta, obj.GetName(), &ing.Spec); err != nil {
+		return true, nil, err
+	}
 
 	processIngressEnabled(shortNameCamel, ing, values)
 
+	hosts, err := processIngressHosts(shortNameCamel, appMeta.Config().IngressDomain, &ing.Spec, values)
+	if err != nil {
+		return true, nil, err
+	}
+
+	if err = processIngressTLSSecrets(shortNameCamel, appMeta, &ing.Spec, values); err != nil {
+		return true, nil, err
+	}
+
 	if err = processIngressClassName(shortNameCamel, &ing.Spec, values); err != nil {
-		return false, nil, err
+		return true, nil, err
 	}
 
-	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": &ing.Spec}, 0)
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&ing.Spec)
 	if err != nil {
 		return true, nil, err
 	}
+	// port templates are inserted after marshalling to keep them on a single line.
+	for i, backend := range ingressServiceBackends(specMap) {
+		backend["port"] = fmt.Sprintf(backendPortPlaceholder, i)
+	}
 
-	a := obj.GetAnnotations()
-	_ = unstructured.SetNestedStringMap(values, a, shortNameCamel, "ingress", "annotations")
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+	for i := len(backendPorts) - 1; i >= 0; i-- {
+		spec = strings.ReplaceAll(spec, fmt.Sprintf(backendPortPlaceholder, i), backendPorts[i])
+	}
+	for placeholder, host := range hosts {
+		spec = strings.ReplaceAll(spec, placeholder, host)
+	}
+	spec = strings.Replace(spec, "  ingressClassName: "+classNamePlaceholder, fmt.Sprintf(classNameTempl, shortNameCamel), 1)
 
 	return true, &ingressResult{
 		name: name + ".yaml",
@@ -89,6 +148,8 @@ func (r ingress) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstruct
 	}, nil
 }
 
+// processIngressClassName moves ingressClassName to <name>.ingress.className value.
+// Value is empty when class is not set and ingressClassName is rendered only for non-empty value.
 func processIngressClassName(shortNameCamel string, ingSpec *networkingv1.IngressSpec, values helmify.Values) error {
 	var className string
 
@@ -96,29 +157,245 @@ func processIngressClassName(shortNameCamel string, ingSpec *networkingv1.Ingres
 		className = *ingSpec.IngressClassName
 	}
 	_ = unstructured.SetNestedField(values, className, shortNameCamel, "ingress", "className")
-	className = fmt.Sprintf("{{.Values.%s.ingress.className}}", shortNameCamel)
+	// class name template is inserted after marshalling to wrap the whole field.
+	className = classNamePlaceholder
 
 	ingSpec.IngressClassName = &className
 	return nil
 }
 
+// processIngressBackendPorts moves service backend ports to <name>.ingress.backends.<service>.port values.
+// The value is a port number or a port name and rendered into 'number' or 'name' field accordingly.
+// Port names shared with other chart objects, e.g. the backend Service, are rendered from portNames values instead.
+// Returns port templates in the order of backends returned by ingressServiceBackends.
+func processIngressBackendPorts(shortNameCamel, ingName string, appMeta helmify.AppMetadata, ingSpec *networkingv1.IngressSpec, values helmify.Values) ([]string, error) {
+	var backends []*networkingv1.IngressServiceBackend
+	if ingSpec.DefaultBackend != nil && ingSpec.DefaultBackend.Service != nil {
+		backends = append(backends, ingSpec.DefaultBackend.Service)
+	}
+	for _, rule := range ingSpec.Rules {
+		if rule.IngressRuleValue.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.IngressRuleValue.HTTP.Paths {
+			if path.Backend.Service != nil {
+				backends = append(backends, path.Backend.Service)
+			}
+		}
+	}
+	res := make([]string, len(backends))
+	for i, backend := range backends {
+		err := processBackendServicePort(appMeta, ingName, backend)
+		if err != nil {
+			return nil, err
+		}
+		if backend.Port.Name != "" && appMeta.IsSharedPortName(backend.Port.Name) {
+			if _, err = processor.TemplatePortName(appMeta, values, backend.Port.Name); err != nil {
+				return nil, err
+			}
+			res[i] = fmt.Sprintf(sharedBackendPortTempl, appMeta.ChartName(), processor.PortNamesKey, strcase.ToLowerCamel(backend.Port.Name))
+			continue
+		}
+		var port interface{} = int64(backend.Port.Number)
+		portStr := strconv.Itoa(int(backend.Port.Number))
+		if backend.Port.Name != "" {
+			port, portStr = backend.Port.Name, backend.Port.Name
+		}
+		key := strcase.ToLowerCamel(appMeta.TrimName(backend.Name))
+		existing, exists, _ := unstructured.NestedFieldNoCopy(values, shortNameCamel, "ingress", "backends", key, "port")
+		if exists && existing != port {
+			// same service referenced with different ports
+			key = strcase.ToLowerCamel(appMeta.TrimName(backend.Name) + "-" + portStr)
+		}
+		err = unstructured.SetNestedField(values, port, shortNameCamel, "ingress", "backends", key, "port")
+		if err != nil {
+			return nil, fmt.Errorf("%w: unable to set ingress backend port value", err)
+		}
+		res[i] = fmt.Sprintf(backendPortTempl, appMeta.ChartName(), shortNameCamel, key)
+	}
+	return res, nil
+}
+
+// processBackendServicePort validates that backend port is exposed by the backend Service from the chart.
+// Numeric port is rewritten to the Service port name if enabled in config.
+// Backends of Services outside the chart are not checked.
+func processBackendServicePort(appMeta helmify.AppMetadata, ingName string, backend *networkingv1.IngressServiceBackend) error {
+	if !appMeta.HasObject("Service", backend.Name) {
+		return nil
+	}
+	port := intstr.FromInt(int(backend.Port.Number))
+	if backend.Port.Name != "" {
+		port = intstr.FromString(backend.Port.Name)
+	}
+	name, _, found := appMeta.ServicePort(backend.Name, port)
+	if !found {
+		if appMeta.Config().Strict {
+			return fmt.Errorf("strict mode: ingress %s backend service %q has no port %s", ingName, backend.Name, port.String())
+		}
+		warning.Report(logrus.WithFields(logrus.Fields{
+			"Ingress": ingName,
+			"Service": backend.Name,
+			"Port":    port.String(),
+		}), "Ingress backend port not found in service.")
+		return nil
+	}
+	if appMeta.Config().IngressBackendPortNames && port.Type == intstr.Int && name != "" {
+		logrus.WithFields(logrus.Fields{
+			"Ingress": ingName,
+			"Service": backend.Name,
+			"Port":    name,
+		}).Debug("ingress backend port number replaced with service port name")
+		backend.Port = networkingv1.ServiceBackendPort{Name: name}
+	}
+	return nil
+}
+
+// ingressServiceBackends returns service backends of unstructured ingress spec:
+// default backend first and then rules paths backends in order of appearance.
+func ingressServiceBackends(specMap map[string]interface{}) []map[string]interface{} {
+	var res []map[string]interface{}
+	if backend, ok, _ := unstructured.NestedFieldNoCopy(specMap, "defaultBackend", "service"); ok {
+		if backendMap, isMap := backend.(map[string]interface{}); isMap {
+			res = append(res, backendMap)
+		}
+	}
+	rules, _, _ := unstructured.NestedFieldNoCopy(specMap, "rules")
+	rulesList, _ := rules.([]interface{})
+	for _, rule := range rulesList {
+		ruleMap, _ := rule.(map[string]interface{})
+		paths, _, _ := unstructured.NestedFieldNoCopy(ruleMap, "http", "paths")
+		pathsList, _ := paths.([]interface{})
+		for _, path := range pathsList {
+			pathMap, _ := path.(map[string]interface{})
+			backend, ok, _ := unstructured.NestedFieldNoCopy(pathMap, "backend", "service")
+			if !ok {
+				continue
+			}
+			if backendMap, isMap := backend.(map[string]interface{}); isMap {
+				res = append(res, backendMap)
+			}
+		}
+	}
+	return res
+}
+
+// processIngressHosts splits hosts within given domain into <name>.ingress.subdomain and shared global.domain values.
+// Ingress with several hosts gets subdomain1, subdomain2, etc. values for the next hosts.
+// Hosts outside the domain and wildcard hosts are kept as they are.
+// Templated hosts are replaced with placeholders, returns host templates by placeholder.
+func processIngressHosts(shortNameCamel, domain string, ingSpec *networkingv1.IngressSpec, values helmify.Values) (map[string]string, error) {
+	if domain == "" {
+		return nil, nil
+	}
+	suffix := "." + strings.Trim(domain, ".")
+	placeholders := map[string]string{}
+	templates := map[string]string{}
+	templateHost := func(host string) (string, error) {
+		if placeholder, ok := placeholders[host]; ok {
+			return placeholder, nil
+		}
+		subdomain := strings.TrimSuffix(host, suffix)
+		if subdomain == host || subdomain == "" || strings.HasPrefix(host, "*") {
+			return host, nil
+		}
+		key := "subdomain"
+		if len(placeholders) != 0 {
+			key += strconv.Itoa(len(placeholders))
+		}
+		err := unstructured.SetNestedField(values, subdomain, shortNameCamel, "ingress", key)
+		if err != nil {
+			return "", fmt.Errorf("%w: unable to set ingress %s value", err, key)
+		}
+		placeholders[host] = fmt.Sprintf(hostPlaceholder, key)
+		templates[placeholders[host]] = fmt.Sprintf(hostTempl, shortNameCamel, key)
+		return placeholders[host], nil
+	}
+	var err error
+	for i := range ingSpec.Rules {
+		if ingSpec.Rules[i].Host == "" {
+			continue
+		}
+		ingSpec.Rules[i].Host, err = templateHost(ingSpec.Rules[i].Host)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i := range ingSpec.TLS {
+		for j := range ingSpec.TLS[i].Hosts {
+			ingSpec.TLS[i].Hosts[j], err = templateHost(ingSpec.TLS[i].Hosts[j])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	return templates, unstructured.SetNestedField(values, strings.Trim(domain, "."), "global", "domain")
+}
+
+// processIngressTLSSecrets templates TLS secret names of Secrets from the chart, including Secrets issued by
+// chart Certificates. Other secret names are moved to <name>.ingress.tlsSecrets.<secret> values.
+func processIngressTLSSecrets(shortNameCamel string, appMeta helmify.AppMetadata, ingSpec *networkingv1.IngressSpec, values helmify.Values) error {
+	for i := range ingSpec.TLS {
+		secretName := ingSpec.TLS[i].SecretName
+		if secretName == "" {
+			continue
+		}
+		if appMeta.HasObject("Secret", secretName) {
+			ingSpec.TLS[i].SecretName = appMeta.TemplatedName(secretName)
+			continue
+		}
+		tpl, err := values.Add(secretName, shortNameCamel, "ingress", "tlsSecrets", secretName)
+		if err != nil {
+			return fmt.Errorf("%w: unable to set ingress TLS secret value", err)
+		}
+		ingSpec.TLS[i].SecretName = tpl
+	}
+	return nil
+}
+
 func processIngressEnabled(shortNameCamel string, ing networkingv1.Ingress, values helmify.Values) {
 	_ = unstructured.SetNestedField(values, true, shortNameCamel, "ingress", "enabled")
 }
 
-func processIngressSpec(appMeta helmify.AppMetadata, ing *networkingv1.IngressSpec) {
+func processIngressSpec(appMeta helmify.AppMetadata, ingName string, ing *networkingv1.IngressSpec) error {
+	var err error
 	if ing.DefaultBackend != nil && ing.DefaultBackend.Service != nil {
-		ing.DefaultBackend.Service.Name = appMeta.TemplatedName(ing.DefaultBackend.Service.Name)
+		ing.DefaultBackend.Service.Name, err = templateBackendService(appMeta, ingName, ing.DefaultBackend.Service.Name)
+		if err != nil {
+			return err
+		}
 	}
 	for i := range ing.Rules {
 		if ing.Rules[i].IngressRuleValue.HTTP != nil {
 			for j := range ing.Rules[i].IngressRuleValue.HTTP.Paths {
 				if ing.Rules[i].IngressRuleValue.HTTP.Paths[j].Backend.Service != nil {
-					ing.Rules[i].IngressRuleValue.HTTP.Paths[j].Backend.Service.Name = appMeta.TemplatedName(ing.Rules[i].IngressRuleValue.HTTP.Paths[j].Backend.Service.Name)
+					ing.Rules[i].IngressRuleValue.HTTP.Paths[j].Backend.Service.Name, err = templateBackendService(appMeta, ingName, ing.Rules[i].IngressRuleValue.HTTP.Paths[j].Backend.Service.Name)
+					if err != nil {
+						return err
+					}
 				}
 			}
 		}
 	}
+	return nil
+}
+
+// templateBackendService templates backend service name if the service is in the chart.
+// Otherwise, the name is left as it is or rejected in strict mode.
+func templateBackendService(appMeta helmify.AppMetadata, ingName, svcName string) (string, error) {
+	if appMeta.HasObject("Service", svcName) {
+		return appMeta.TemplatedName(svcName), nil
+	}
+	if appMeta.Config().Strict {
+		return "", fmt.Errorf("strict mode: ingress %s backend service %q not found in chart", ingName, svcName)
+	}
+	warning.Report(logrus.WithFields(logrus.Fields{
+		"Ingress": ingName,
+		"Service": svcName,
+	}), "Ingress backend service not found in chart: keeping service name as it is.")
+	return svcName, nil
 }
 
 type ingressResult struct {
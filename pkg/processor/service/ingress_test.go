@@ -1,12 +1,17 @@
 package service
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
+
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 const ingressYaml = `apiVersion: networking.k8s.io/v1
@@ -27,6 +32,46 @@ spec:
                 port:
                   number: 8443`
 
+const ingressSvcYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: myapp-service
+spec:
+  ports:
+  - port: 8443`
+
+const ingressPortsYaml = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: myapp-ingress
+spec:
+  rules:
+    - http:
+        paths:
+          - path: /api
+            pathType: Prefix
+            backend:
+              service:
+                name: myapp-api
+                port:
+                  number: 80
+          - path: /web
+            pathType: Prefix
+            backend:
+              service:
+                name: myapp-web
+                port:
+                  name: http`
+
+const ingressNamedPortSvcYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: myapp-service
+spec:
+  ports:
+  - name: https
+    port: 8443`
+
 func Test_ingress_Process(t *testing.T) {
 	var testInstance ingress
 
@@ -36,6 +81,139 @@ func Test_ingress_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, true, processed)
 	})
+	t.Run("backend ports", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressPortsYaml)
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart"}), obj)
+		assert.NoError(t, err)
+		backends := tmpl.Values()["myappIngress"].(map[string]interface{})["ingress"].(map[string]interface{})["backends"]
+		assert.Equal(t, map[string]interface{}{
+			"myappApi": map[string]interface{}{"port": int64(80)},
+			"myappWeb": map[string]interface{}{"port": "http"},
+		}, backends)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `port: {{ include "chart.ingressBackendPort" .Values.myappIngress.ingress.backends.myappApi.port }}`)
+		assert.Contains(t, buf.String(), `port: {{ include "chart.ingressBackendPort" .Values.myappIngress.ingress.backends.myappWeb.port }}`)
+	})
+	t.Run("backend service in chart", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(ingressSvcYaml))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `name: {{ include "chart.fullname" . }}-service`)
+	})
+	t.Run("backend service missing", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "name: myapp-service\n")
+
+		obj = internal.GenerateObj(ingressYaml)
+		appMeta = metadata.New(config.Config{ChartName: "chart", Strict: true})
+		appMeta.Load(obj)
+		_, _, err = testInstance.Process(appMeta, obj)
+		assert.ErrorContains(t, err, `backend service "myapp-service" not found in chart`)
+	})
+	t.Run("backend port linked to service", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart", IngressBackendPortNames: true})
+		appMeta.Load(internal.GenerateObj(ingressNamedPortSvcYaml))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		backends := tmpl.Values()["ingress"].(map[string]interface{})["ingress"].(map[string]interface{})["backends"]
+		assert.Equal(t, map[string]interface{}{"service": map[string]interface{}{"port": "https"}}, backends)
+
+		obj = internal.GenerateObj(ingressYaml)
+		appMeta = metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(ingressNamedPortSvcYaml))
+		appMeta.Load(obj)
+		_, tmpl, err = testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		backends = tmpl.Values()["ingress"].(map[string]interface{})["ingress"].(map[string]interface{})["backends"]
+		assert.Equal(t, map[string]interface{}{"service": map[string]interface{}{"port": int64(8443)}}, backends)
+	})
+	t.Run("backend port name shared with service", func(t *testing.T) {
+		obj := internal.GenerateObj(strings.Replace(ingressYaml, "number: 8443", "name: https", 1))
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(ingressNamedPortSvcYaml))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `port: {{ include "chart.ingressBackendPort" .Values.portNames.https }}`)
+		assert.Equal(t, map[string]interface{}{"https": "https"}, tmpl.Values()["portNames"])
+		assert.NotContains(t, tmpl.Values()["ingress"].(map[string]interface{})["ingress"], "backends")
+	})
+	t.Run("backend port missing in service", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressPortsYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart", Strict: true})
+		appMeta.Load(internal.GenerateObj(ingressSvcYaml))
+		appMeta.Load(internal.GenerateObj(strings.ReplaceAll(ingressSvcYaml, "myapp-service", "myapp-api")))
+		appMeta.Load(obj)
+		_, _, err := testInstance.Process(appMeta, obj)
+		assert.ErrorContains(t, err, `backend service "myapp-api" has no port 80`)
+	})
+	t.Run("hosts in shared domain", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressYaml)
+		_ = unstructured.SetNestedSlice(obj.Object, []interface{}{
+			map[string]interface{}{"host": "api.example.com"},
+			map[string]interface{}{"host": "admin.api.example.com"},
+			map[string]interface{}{"host": "*.example.com"},
+			map[string]interface{}{"host": "api.example.org"},
+		}, "spec", "rules")
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart", IngressDomain: "example.com"}), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `host: {{ printf "%s.%s" .Values.myappIngress.ingress.subdomain .Values.global.domain }}`)
+		assert.Contains(t, buf.String(), `host: {{ printf "%s.%s" .Values.myappIngress.ingress.subdomain1 .Values.global.domain }}`)
+		assert.NotContains(t, buf.String(), "subdomain2")
+		assert.Contains(t, buf.String(), `host: api.example.org`)
+		ingValues := tmpl.Values()["myappIngress"].(map[string]interface{})["ingress"].(map[string]interface{})
+		assert.Equal(t, "api", ingValues["subdomain"])
+		assert.Equal(t, "admin.api", ingValues["subdomain1"])
+		assert.Equal(t, map[string]interface{}{"domain": "example.com"}, tmpl.Values()["global"])
+	})
+	t.Run("class name", func(t *testing.T) {
+		obj := internal.GenerateObj(ingressYaml)
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart"}), obj)
+		assert.NoError(t, err)
+		assert.Equal(t, "", tmpl.Values()["myappIngress"].(map[string]interface{})["ingress"].(map[string]interface{})["className"])
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `  {{- with .Values.myappIngress.ingress.className }}
+  ingressClassName: {{ . }}
+  {{- end }}`)
+
+		obj = internal.GenerateObj(ingressYaml)
+		_ = unstructured.SetNestedField(obj.Object, "nginx", "spec", "ingressClassName")
+		_, tmpl, err = testInstance.Process(metadata.New(config.Config{ChartName: "chart"}), obj)
+		assert.NoError(t, err)
+		assert.Equal(t, "nginx", tmpl.Values()["myappIngress"].(map[string]interface{})["ingress"].(map[string]interface{})["className"])
+	})
+	t.Run("annotations of prefixed name", func(t *testing.T) {
+		obj := internal.GenerateObj(strings.Replace(ingressYaml, "name: myapp-ingress", "name: controller-manager-ingress", 1))
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart", IngressExtraAnnotations: true}), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- include "chart.annotations" (dict "annotations" .Values.controllerManagerIngress.ingress.annotations "extra" .Values.controllerManagerIngress.ingress.extraAnnotations "context" $) | nindent 4 }}`)
+		assert.Equal(t, map[string]interface{}{
+			"annotations":      map[string]interface{}{"nginx.ingress.kubernetes.io/rewrite-target": "/"},
+			"extraAnnotations": map[string]interface{}{},
+		}, tmpl.Values()["controllerManagerIngress"].(map[string]interface{})["ingress"])
+		assert.NotContains(t, tmpl.Values()["ingress"].(map[string]interface{})["ingress"], "annotations")
+	})
 	t.Run("skipped", func(t *testing.T) {
 		obj := internal.TestNs
 		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/arttor/helmify/pkg/processor"
@@ -11,6 +13,7 @@ import (
 	"github.com/arttor/helmify/pkg/helmify"
 	yamlformat "github.com/arttor/helmify/pkg/yaml"
 	"github.com/iancoleman/strcase"
+	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -22,12 +25,27 @@ import (
 const (
 	svcTempSpec = `
 spec:
-  type: {{ .Values.%[1]s.type }}
+  type: {{ .Values.%[1]s.type }}%[4]s
   selector:
 %[2]s
-  {{- include "%[3]s.selectorLabels" . | nindent 4 }}
   ports:
 	{{- .Values.%[1]s.ports | toYaml | nindent 2 -}}`
+	// svcTempSpecTpl - renders ports with tpl because port names shared with other objects are templated in values.
+	svcTempSpecTpl = `
+spec:
+  type: {{ .Values.%[1]s.type }}%[4]s
+  selector:
+%[2]s
+  ports:
+	{{- tpl (toYaml .Values.%[1]s.ports) . | nindent 2 -}}`
+	svcSelectorLabels = `
+  {{- include "%s.selectorLabels" . | nindent 4 }}`
+	annotationPlaceholder  = "helmifyServiceAnnotation%d"
+	annotationTempl        = `{{ index .Values.%s.annotations %q | quote }}`
+	activeColorKey         = "activeColor"
+	defaultActiveColor     = "blue"
+	activeColorTempl       = "{{ .Values.activeColor | quote }}"
+	activeColorPlaceholder = "helmifyActiveColor"
 )
 
 var svcGVC = schema.GroupVersionKind{
@@ -54,20 +72,27 @@ func (r svc) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		return true, nil, fmt.Errorf("%w: unable to cast to service", err)
 	}
 
-	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	name := appMeta.TrimName(obj.GetName())
+	shortName := strings.TrimPrefix(name, "controller-manager-")
+	shortNameCamel := strcase.ToLowerCamel(shortName)
+
+	values := helmify.Values{}
+	annotations, err := processAnnotations(appMeta, obj, shortNameCamel, values)
 	if err != nil {
 		return true, nil, err
 	}
 
-	name := appMeta.TrimName(obj.GetName())
-	shortName := strings.TrimPrefix(name, "controller-manager-")
-	shortNameCamel := strcase.ToLowerCamel(shortName)
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+	// annotation templates are inserted after marshalling to keep them on a single line.
+	for i := len(annotations) - 1; i >= 0; i-- {
+		meta = strings.ReplaceAll(meta, fmt.Sprintf(annotationPlaceholder, i), annotations[i])
+	}
 
-	selector, _ := yaml.Marshal(service.Spec.Selector)
-	selector = yamlformat.Indent(selector, 4)
-	selector = bytes.TrimRight(selector, "\n ")
+	selector := processSelector(appMeta, obj.GetName(), service.Spec.Selector, values)
 
-	values := helmify.Values{}
 	svcType := service.Spec.Type
 	if svcType == "" {
 		svcType = corev1.ServiceTypeClusterIP
@@ -87,6 +112,10 @@ func (r svc) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		if p.Protocol != "" {
 			pMap["protocol"] = string(p.Protocol)
 		}
+		// application protocol hints ingress controllers, e.g. grpc or kubernetes.io/h2c backends
+		if p.AppProtocol != nil {
+			pMap["appProtocol"] = *p.AppProtocol
+		}
 		if p.TargetPort.Type == intstr.Int {
 			pMap["targetPort"] = int64(p.TargetPort.IntVal)
 		} else {
@@ -94,8 +123,21 @@ func (r svc) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 		}
 		ports[i] = pMap
 	}
+	portNames := helmify.Values{}
+	err = processor.TemplatePortFields(appMeta, portNames, ports, "name", "targetPort")
+	if err != nil {
+		return true, nil, err
+	}
+	tempSpec := svcTempSpec
+	if len(portNames) != 0 {
+		tempSpec = svcTempSpecTpl
+		err = values.Merge(portNames)
+		if err != nil {
+			return true, nil, err
+		}
+	}
 	_ = unstructured.SetNestedSlice(values, ports, shortNameCamel, "ports")
-	res := meta + fmt.Sprintf(svcTempSpec, shortNameCamel, selector, appMeta.ChartName())
+	res := meta + fmt.Sprintf(tempSpec, shortNameCamel, selector, appMeta.ChartName(), processClusterIP(service.Spec))
 	return true, &result{
 		name:   shortName,
 		data:   res,
@@ -103,6 +145,121 @@ func (r svc) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured
 	}, nil
 }
 
+// processAnnotations moves values of Service annotations with keys matching ServiceAnnotationValues config expressions
+// to <name>.annotations value keyed by annotation key. Annotation values are replaced with placeholders of returned
+// templates. Values are rendered with quote, so multi-line and comma separated values are kept as they are.
+func processAnnotations(appMeta helmify.AppMetadata, obj *unstructured.Unstructured, nameCamel string, values helmify.Values) ([]string, error) {
+	exprs := appMeta.Config().ServiceAnnotationValues
+	annotations := obj.GetAnnotations()
+	if len(exprs) == 0 || len(annotations) == 0 {
+		return nil, nil
+	}
+	var matchers []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid service annotation expression %q", err, expr)
+		}
+		matchers = append(matchers, re)
+	}
+	keys := make([]string, 0, len(annotations))
+	for k := range annotations {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var templates []string
+	for _, k := range keys {
+		if !matchAny(matchers, k) {
+			continue
+		}
+		if err := unstructured.SetNestedField(values, annotations[k], nameCamel, "annotations", k); err != nil {
+			return nil, fmt.Errorf("%w: unable to set annotation %s value", err, k)
+		}
+		annotations[k] = fmt.Sprintf(annotationPlaceholder, len(templates))
+		templates = append(templates, fmt.Sprintf(annotationTempl, nameCamel, k))
+	}
+	obj.SetAnnotations(annotations)
+	return templates, nil
+}
+
+func matchAny(matchers []*regexp.Regexp, s string) bool {
+	for _, re := range matchers {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// processSelector returns Service selector template. If the Service fronts a chart workload, the selector is rewritten
+// to the workload selector labels, so it keeps matching pods when other pod labels are overridden in values.
+// Chart selector labels are added if the workload template adds them to the pods.
+func processSelector(appMeta helmify.AppMetadata, svcName string, selector map[string]string, values helmify.Values) string {
+	withChartLabels := true
+	colorLabel := appMeta.Config().BlueGreenLabel
+	color, colored := selector[colorLabel]
+	colored = colored && colorLabel != ""
+	gvk, workload, workloadSelector, ok := appMeta.PodSelector(withoutLabel(selector, colorLabel))
+	if ok {
+		logrus.WithFields(logrus.Fields{
+			"Service":  svcName,
+			"Workload": gvk.Kind + "/" + workload,
+		}).Debug("service selector rewritten to workload selector labels")
+		selector = workloadSelector
+		withChartLabels = hasChartSelectorLabels(gvk)
+	}
+	if colored {
+		// blue-green: traffic is routed to pods of the active color
+		if color == "" {
+			color = defaultActiveColor
+		}
+		_ = unstructured.SetNestedField(values, color, activeColorKey)
+		selector = withoutLabel(selector, colorLabel)
+		selector[colorLabel] = activeColorPlaceholder
+	}
+	res, _ := yaml.Marshal(selector)
+	res = yamlformat.Indent(res, 4)
+	res = bytes.TrimRight(res, "\n ")
+	res = bytes.Replace(res, []byte(activeColorPlaceholder), []byte(activeColorTempl), 1)
+	if withChartLabels {
+		res = append(res, fmt.Sprintf(svcSelectorLabels, appMeta.ChartName())...)
+	}
+	return string(res)
+}
+
+// withoutLabel returns copy of labels without given key.
+func withoutLabel(labels map[string]string, key string) map[string]string {
+	res := make(map[string]string, len(labels))
+	for k, v := range labels {
+		if k != key {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+// hasChartSelectorLabels returns true if chart template of given workload kind adds chart selector labels to pods.
+func hasChartSelectorLabels(gvk schema.GroupVersionKind) bool {
+	switch gvk.Kind {
+	case "Deployment", "DaemonSet", "StatefulSet", "ReplicationController":
+		return true
+	}
+	return false
+}
+
+// processClusterIP keeps headless service clusterIP. Allocated clusterIP and clusterIPs are assigned by the
+// cluster and are not rendered.
+func processClusterIP(spec corev1.ServiceSpec) string {
+	headless := spec.ClusterIP == corev1.ClusterIPNone
+	if len(spec.ClusterIPs) != 0 && spec.ClusterIPs[0] == corev1.ClusterIPNone {
+		headless = true
+	}
+	if !headless {
+		return ""
+	}
+	return "\n  clusterIP: None"
+}
+
 type result struct {
 	name   string
 	data   string
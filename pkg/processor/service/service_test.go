@@ -1,8 +1,11 @@
 package service
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -24,6 +27,74 @@ spec:
   selector:
     control-plane: controller-manager`
 
+const svcAllocatedYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-api
+spec:
+  clusterIP: 10.96.12.34
+  clusterIPs:
+  - 10.96.12.34
+  - fd00:10:96::1234
+  ipFamilyPolicy: PreferDualStack
+  selector:
+    app: api
+  ports:
+  - port: 80`
+
+const svcHeadlessYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-db
+spec:
+  clusterIP: None
+  clusterIPs:
+  - None
+  selector:
+    app: db
+  ports:
+  - port: 5432`
+
+const svcWebYaml = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    app: web
+    tier: frontend
+  ports:
+  - port: 80`
+
+const deplWebYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-web
+spec:
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: frontend`
+
+const deplBackendYaml = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: my-app-backend
+spec:
+  selector:
+    matchLabels:
+      app: web
+      tier: backend
+  template:
+    metadata:
+      labels:
+        app: web
+        tier: backend`
+
 func Test_svc_Process(t *testing.T) {
 	var testInstance svc
 
@@ -39,4 +110,75 @@ func Test_svc_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
+	t.Run("allocated cluster IPs stripped", func(t *testing.T) {
+		obj := internal.GenerateObj(svcAllocatedYaml)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.NotContains(t, buf.String(), "clusterIP")
+		assert.NotContains(t, buf.String(), "10.96.12.34")
+	})
+	t.Run("headless cluster IP preserved", func(t *testing.T) {
+		obj := internal.GenerateObj(svcHeadlessYaml)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "\n  clusterIP: None\n  selector:")
+		assert.NotContains(t, buf.String(), "clusterIPs")
+	})
+	t.Run("selector rewritten to workload selector", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(deplWebYaml))
+		appMeta.Load(internal.GenerateObj(svcWebYaml))
+		_, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(svcWebYaml))
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `  selector:
+    app: web
+  {{- include "chart.selectorLabels" . | nindent 4 }}
+  ports:`)
+		assert.NotContains(t, buf.String(), "tier")
+	})
+	t.Run("workload selector matching other workload pods keeps selector", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(deplWebYaml))
+		appMeta.Load(internal.GenerateObj(deplBackendYaml))
+		appMeta.Load(internal.GenerateObj(svcWebYaml))
+		_, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(svcWebYaml))
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "  selector:\n    app: web\n    tier: frontend\n")
+	})
+	t.Run("replicaset pods have no chart selector labels", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(strings.ReplaceAll(deplWebYaml, "Deployment", "ReplicaSet")))
+		appMeta.Load(internal.GenerateObj(svcWebYaml))
+		_, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(svcWebYaml))
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), "  selector:\n    app: web\n  ports:")
+	})
+	t.Run("no workload keeps selector", func(t *testing.T) {
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		_, tmpl, err := testInstance.Process(appMeta, internal.GenerateObj(svcWebYaml))
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `  selector:
+    app: web
+    tier: frontend
+  {{- include "chart.selectorLabels" . | nindent 4 }}`)
+	})
+	t.Run("app protocol kept", func(t *testing.T) {
+		obj := internal.GenerateObj(strings.ReplaceAll(svcHeadlessYaml, "- port: 5432", "- port: 5432\n    appProtocol: kubernetes.io/h2c"))
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		ports := tmpl.Values()["myAppDb"].(map[string]interface{})["ports"].([]interface{})
+		assert.Equal(t, "kubernetes.io/h2c", ports[0].(map[string]interface{})["appProtocol"])
+	})
 }
@@ -26,7 +26,13 @@ var statefulsetGVC = schema.GroupVersionKind{
 var statefulsetTempl, _ = template.New("statefulset").Parse(
 	`{{- .Meta }}
 spec:
-{{ .Spec }}`)
+{{ .Spec }}
+{{- if .RetentionPolicy }}
+{{ .RetentionPolicy }}
+{{- end }}`)
+
+const retentionPolicyTempl = `  persistentVolumeClaimRetentionPolicy:
+    {{- toYaml .Values.%[1]s.persistence.retentionPolicy | nindent 4 }}`
 
 // New creates processor for k8s StatefulSet resource.
 func New() helmify.Processor {
@@ -107,16 +113,17 @@ func (d statefulset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unst
 		}
 	}
 
-	// process pod spec:
-	podSpecMap, podValues, err := pod.ProcessSpec(nameCamel, appMeta, ssSpec.Template.Spec)
+	retentionPolicy, err := processRetentionPolicy(nameCamel, ssSpecMap, &values)
 	if err != nil {
 		return true, nil, err
 	}
-	err = values.Merge(podValues)
-	if err != nil {
-		return true, nil, err
+
+	// process pod template:
+	var selector map[string]string
+	if ssSpec.Selector != nil {
+		selector = ssSpec.Selector.MatchLabels
 	}
-	err = unstructured.SetNestedMap(ssSpecMap, podSpecMap, "template", "spec")
+	err = pod.ProcessTemplateSpec(nameCamel, appMeta, obj, ssSpec.Template, selector, ssSpecMap, &values, 8, "template")
 	if err != nil {
 		return true, nil, err
 	}
@@ -126,23 +133,45 @@ func (d statefulset) Process(appMeta helmify.AppMetadata, obj *unstructured.Unst
 		return true, nil, err
 	}
 	spec = strings.ReplaceAll(spec, "'", "")
+	spec = pod.RenderPlaceholders(spec)
 
 	return true, &result{
 		values: values,
 		data: struct {
-			Meta string
-			Spec string
+			Meta            string
+			Spec            string
+			RetentionPolicy string
 		}{
-			Meta: meta,
-			Spec: spec,
+			Meta:            meta,
+			Spec:            spec,
+			RetentionPolicy: retentionPolicy,
 		},
 	}, nil
 }
 
+// processRetentionPolicy moves persistentVolumeClaimRetentionPolicy from spec to values.
+// The field is rendered only for k8s >= 1.27 where it is enabled by default.
+func processRetentionPolicy(nameCamel string, ssSpecMap map[string]interface{}, values *helmify.Values) (string, error) {
+	policy, exists, err := unstructured.NestedMap(ssSpecMap, "persistentVolumeClaimRetentionPolicy")
+	if err != nil {
+		return "", err
+	}
+	delete(ssSpecMap, "persistentVolumeClaimRetentionPolicy")
+	if !exists || len(policy) == 0 {
+		return "", nil
+	}
+	err = unstructured.SetNestedMap(*values, policy, nameCamel, "persistence", "retentionPolicy")
+	if err != nil {
+		return "", err
+	}
+	return processor.KubeVersionGuard("1.27", fmt.Sprintf(retentionPolicyTempl, nameCamel)), nil
+}
+
 type result struct {
 	data struct {
-		Meta string
-		Spec string
+		Meta            string
+		Spec            string
+		RetentionPolicy string
 	}
 	values helmify.Values
 }
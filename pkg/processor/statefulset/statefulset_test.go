@@ -0,0 +1,152 @@
+package statefulset
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	strStatefulSet = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: web
+spec:
+  serviceName: "nginx"
+  replicas: 2
+  selector:
+    matchLabels:
+      app: nginx
+  template:
+    metadata:
+      labels:
+        app: nginx
+    spec:
+      containers:
+        - name: nginx
+          image: registry.k8s.io/nginx-slim:0.8
+  volumeClaimTemplates:
+    - metadata:
+        name: www
+      spec:
+        accessModes: [ "ReadWriteOnce" ]
+        resources:
+          requests:
+            storage: 1Gi`
+	strHeadlessService = `apiVersion: v1
+kind: Service
+metadata:
+  name: my-app-headless
+spec:
+  clusterIP: None
+  selector:
+    app: db
+  ports:
+  - port: 5432`
+	strStatefulSetSubdomain = `apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: my-app-db
+spec:
+  serviceName: my-app-headless
+  selector:
+    matchLabels:
+      app: db
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      subdomain: my-app-headless
+      setHostnameAsFQDN: true
+      containers:
+        - name: db
+          image: postgres:15`
+	strStatefulSetRetention = strStatefulSet + `
+  persistentVolumeClaimRetentionPolicy:
+    whenDeleted: Retain
+    whenScaled: Delete`
+)
+
+func Test_statefulset_Process(t *testing.T) {
+	var testInstance statefulset
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSet)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("retention policy absent", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSet)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.NotContains(t, buf.String(), "persistentVolumeClaimRetentionPolicy")
+		assert.NotContains(t, tmpl.Values()["web"], "persistence")
+	})
+	t.Run("retention policy externalized", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSetRetention)
+		_, tmpl, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `{{- if semverCompare ">=1.27-0" .Capabilities.KubeVersion.Version }}
+  persistentVolumeClaimRetentionPolicy:
+    {{- toYaml .Values.web.persistence.retentionPolicy | nindent 4 }}
+{{- end }}`)
+		assert.Equal(t, map[string]interface{}{
+			"retentionPolicy": map[string]interface{}{
+				"whenDeleted": "Retain",
+				"whenScaled":  "Delete",
+			},
+		}, tmpl.Values()["web"].(map[string]interface{})["persistence"])
+	})
+	t.Run("subdomain of headless service", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSetSubdomain)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(strHeadlessService))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `subdomain: {{ include "chart.fullname" . }}-headless`)
+		assert.Contains(t, buf.String(), `setHostnameAsFQDN: {{ .Values.db.setHostnameAsFQDN }}`)
+		assert.Equal(t, true, tmpl.Values()["db"].(map[string]interface{})["setHostnameAsFQDN"])
+		assert.NotContains(t, tmpl.Values()["db"], "subdomain")
+	})
+	t.Run("external subdomain", func(t *testing.T) {
+		obj := internal.GenerateObj(strings.ReplaceAll(strStatefulSetSubdomain, "subdomain: my-app-headless", "subdomain: other"))
+		_, tmpl, err := testInstance.Process(metadata.New(config.Config{ChartName: "chart"}), obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `subdomain: {{ .Values.myAppDb.subdomain | quote }}`)
+		assert.Equal(t, "other", tmpl.Values()["myAppDb"].(map[string]interface{})["subdomain"])
+	})
+	t.Run("subdomain of chart object other than service", func(t *testing.T) {
+		obj := internal.GenerateObj(strStatefulSetSubdomain)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(strings.ReplaceAll(strHeadlessService, "kind: Service", "kind: ConfigMap")))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `subdomain: {{ .Values.db.subdomain | quote }}`)
+		assert.Equal(t, "my-app-headless", tmpl.Values()["db"].(map[string]interface{})["subdomain"])
+	})
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/arttor/helmify/pkg/helmify"
+	"github.com/arttor/helmify/pkg/processor"
+	yamlformat "github.com/arttor/helmify/pkg/yaml"
+	"github.com/iancoleman/strcase"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var pvTempl, _ = template.New("pv").Parse(
+	`{{ .If }}
+{{ .Meta }}
+{{ .Spec }}
+{{ .End }}`)
+
+var pvGVC = schema.GroupVersionKind{
+	Group:   "",
+	Version: "v1",
+	Kind:    "PersistentVolume",
+}
+
+// NewPV creates processor for k8s PersistentVolume resource.
+func NewPV() helmify.Processor {
+	return &pv{}
+}
+
+type pv struct{}
+
+// Process k8s PersistentVolume object into template. Returns false if not capable of processing given resource type.
+// PersistentVolume is cluster-scoped: it is rendered without namespace, only claimRef of in-chart PVC gets release namespace.
+func (p pv) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructured) (bool, helmify.Template, error) {
+	if obj.GroupVersionKind() != pvGVC {
+		return false, nil, nil
+	}
+	meta, err := processor.ProcessObjMeta(appMeta, obj)
+	if err != nil {
+		return true, nil, err
+	}
+
+	name := appMeta.TrimName(obj.GetName())
+	nameCamelCase := strcase.ToLowerCamel(name)
+	values := helmify.Values{}
+
+	volume := corev1.PersistentVolume{}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &volume)
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable to cast to PV", err)
+	}
+
+	if volume.Spec.StorageClassName != "" {
+		volume.Spec.StorageClassName, err = values.Add(volume.Spec.StorageClassName, "pv", nameCamelCase, "storageClass")
+		if err != nil {
+			return true, nil, err
+		}
+	}
+	if volume.Spec.PersistentVolumeReclaimPolicy != "" {
+		reclaimPolicy, err := values.Add(string(volume.Spec.PersistentVolumeReclaimPolicy), "pv", nameCamelCase, "reclaimPolicy")
+		if err != nil {
+			return true, nil, err
+		}
+		volume.Spec.PersistentVolumeReclaimPolicy = corev1.PersistentVolumeReclaimPolicy(reclaimPolicy)
+	}
+	if ref := volume.Spec.ClaimRef; ref != nil && appMeta.HasObject("PersistentVolumeClaim", ref.Name) {
+		// claim is bound by name and namespace, uid and resourceVersion belong to the source cluster
+		volume.Spec.ClaimRef = &corev1.ObjectReference{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       appMeta.TemplatedName(ref.Name),
+			Namespace:  "{{ .Release.Namespace }}",
+		}
+	}
+
+	specMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&volume.Spec)
+	if err != nil {
+		return true, nil, err
+	}
+	capacity, ok, _ := unstructured.NestedString(specMap, "capacity", "storage")
+	if ok {
+		templatedCapacity, err := values.Add(capacity, "pv", nameCamelCase, "storage")
+		if err != nil {
+			return true, nil, err
+		}
+		err = unstructured.SetNestedField(specMap, templatedCapacity, "capacity", "storage")
+		if err != nil {
+			return true, nil, err
+		}
+	}
+	_ = unstructured.SetNestedField(values, true, "pv", nameCamelCase, "enabled")
+
+	spec, err := yamlformat.Marshal(map[string]interface{}{"spec": specMap}, 0)
+	if err != nil {
+		return true, nil, err
+	}
+	spec = strings.ReplaceAll(spec, "'", "")
+
+	return true, &pvResult{
+		name: name + ".yaml",
+		data: struct {
+			If   string
+			Meta string
+			Spec string
+			End  string
+		}{
+			If:   fmt.Sprintf("{{- if .Values.pv.%s.enabled }}", nameCamelCase),
+			Meta: meta,
+			Spec: spec,
+			End:  "{{- end }}",
+		},
+		values: values,
+	}, nil
+}
+
+type pvResult struct {
+	name string
+	data struct {
+		If   string
+		Meta string
+		Spec string
+		End  string
+	}
+	values helmify.Values
+}
+
+func (r *pvResult) Filename() string {
+	return r.name
+}
+
+func (r *pvResult) Values() helmify.Values {
+	return r.values
+}
+
+func (r *pvResult) Write(writer io.Writer) error {
+	return pvTempl.Execute(writer, r.data)
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/arttor/helmify/internal"
+	"github.com/arttor/helmify/pkg/config"
+	"github.com/arttor/helmify/pkg/metadata"
+	"github.com/stretchr/testify/assert"
+)
+
+const pvYaml = `apiVersion: v1
+kind: PersistentVolume
+metadata:
+  name: my-app-pv
+spec:
+  storageClassName: manual
+  persistentVolumeReclaimPolicy: Retain
+  capacity:
+    storage: 10Gi
+  accessModes:
+    - ReadWriteOnce
+  hostPath:
+    path: /mnt/data
+  claimRef:
+    name: my-app-claim
+    namespace: my-ns
+    uid: 6f1ad4b3-7f4e-4e3a-9f3c-1d2b3c4d5e6f`
+
+const pvClaimYaml = `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: my-app-claim
+  namespace: my-ns
+spec:
+  accessModes:
+    - ReadWriteOnce
+  resources:
+    requests:
+      storage: 10Gi`
+
+func Test_PV_Process(t *testing.T) {
+	var testInstance pv
+
+	t.Run("processed", func(t *testing.T) {
+		obj := internal.GenerateObj(pvYaml)
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, true, processed)
+	})
+	t.Run("skipped", func(t *testing.T) {
+		obj := internal.TestNs
+		processed, _, err := testInstance.Process(&metadata.Service{}, obj)
+		assert.NoError(t, err)
+		assert.Equal(t, false, processed)
+	})
+	t.Run("host path volume", func(t *testing.T) {
+		obj := internal.GenerateObj(pvYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(internal.GenerateObj(pvClaimYaml))
+		appMeta.Load(obj)
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		out := buf.String()
+		assert.Contains(t, out, "{{- if .Values.pv.pv.enabled }}")
+		assert.Contains(t, out, "    storage: {{ .Values.pv.pv.storage | quote }}")
+		assert.Contains(t, out, "  persistentVolumeReclaimPolicy: {{ .Values.pv.pv.reclaimPolicy | quote }}")
+		assert.Contains(t, out, "  storageClassName: {{ .Values.pv.pv.storageClass | quote }}")
+		assert.Contains(t, out, "  hostPath:\n    path: /mnt/data")
+		assert.Contains(t, out, `  claimRef:
+    name: {{ include "chart.fullname" . }}-claim
+    namespace: {{ .Release.Namespace }}`)
+		assert.NotContains(t, out, "uid:")
+		assert.NotContains(t, out, "my-ns")
+
+		assert.Equal(t, map[string]interface{}{
+			"enabled":       true,
+			"storage":       "10Gi",
+			"reclaimPolicy": "Retain",
+			"storageClass":  "manual",
+		}, tmpl.Values()["pv"].(map[string]interface{})["pv"])
+	})
+}
@@ -65,7 +65,10 @@ func (c cert) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 	for _, dnsName := range dnsNames {
 		dns := dnsName.(string)
 		templatedDns := appMeta.TemplatedString(dns)
-		processedDns := strings.ReplaceAll(templatedDns, appMeta.Namespace(), "{{ .Release.Namespace }}")
+		processedDns := templatedDns
+		if appMeta.Namespace() != "" {
+			processedDns = strings.ReplaceAll(processedDns, appMeta.Namespace(), "{{ .Release.Namespace }}")
+		}
 		processedDns = strings.ReplaceAll(processedDns, cluster.DefaultDomain, fmt.Sprintf("{{ .Values.%s }}", cluster.DomainKey))
 		processedDnsNames = append(processedDnsNames, processedDns)
 	}
@@ -83,6 +86,16 @@ func (c cert) Process(appMeta helmify.AppMetadata, obj *unstructured.Unstructure
 	if err != nil {
 		return true, nil, fmt.Errorf("%w: unable set cert issuerRef", err)
 	}
+	secretName, _, err := unstructured.NestedString(obj.Object, "spec", "secretName")
+	if err != nil {
+		return true, nil, fmt.Errorf("%w: unable get cert secretName", err)
+	}
+	if secretName != "" {
+		err = unstructured.SetNestedField(obj.Object, appMeta.TemplatedName(secretName), "spec", "secretName")
+		if err != nil {
+			return true, nil, fmt.Errorf("%w: unable set cert secretName", err)
+		}
+	}
 	spec, _ := yaml.Marshal(obj.Object["spec"])
 	spec = yamlformat.Indent(spec, 2)
 	spec = bytes.TrimRight(spec, "\n ")
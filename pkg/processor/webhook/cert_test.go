@@ -1,8 +1,10 @@
 package webhook
 
 import (
+	"bytes"
 	"testing"
 
+	"github.com/arttor/helmify/pkg/config"
 	"github.com/arttor/helmify/pkg/metadata"
 
 	"github.com/arttor/helmify/internal"
@@ -38,4 +40,28 @@ func Test_cert_Process(t *testing.T) {
 		assert.NoError(t, err)
 		assert.Equal(t, false, processed)
 	})
+	t.Run("secret name templated", func(t *testing.T) {
+		obj := internal.GenerateObj(certYaml)
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(obj)
+		assert.True(t, appMeta.HasObject("Secret", "webhook-server-cert"))
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `secretName: '{{ include "chart.fullname" . }}-webhook-server-cert'`)
+	})
+	t.Run("dns names without namespace", func(t *testing.T) {
+		obj := internal.GenerateObj(certYaml)
+		obj.SetNamespace("")
+		appMeta := metadata.New(config.Config{ChartName: "chart"})
+		appMeta.Load(obj)
+		assert.Equal(t, "", appMeta.Namespace())
+		_, tmpl, err := testInstance.Process(appMeta, obj)
+		assert.NoError(t, err)
+		buf := bytes.Buffer{}
+		assert.NoError(t, tmpl.Write(&buf))
+		assert.Contains(t, buf.String(), `- '{{ include "chart.fullname" . }}-my-operator-webhook-service.my-operator-system.svc'`)
+		assert.NotContains(t, buf.String(), "Release.Namespace")
+	})
 }
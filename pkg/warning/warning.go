@@ -0,0 +1,69 @@
+package warning
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	mu        sync.Mutex
+	collector *Collector
+)
+
+// Collector - collects warnings reported while the chart is generated, e.g. dangling references or unsupported
+// resources.
+type Collector struct {
+	warnings []string
+}
+
+// Collect starts collecting reported warnings. Returned function stops collecting.
+func Collect() (*Collector, func()) {
+	c := &Collector{}
+	mu.Lock()
+	defer mu.Unlock()
+	collector = c
+	return c, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if collector == c {
+			collector = nil
+		}
+	}
+}
+
+// Report logs warning message with fields of given log entry and passes it to the collector.
+// Warning is collected regardless of the log level.
+func Report(log *logrus.Entry, msg string) {
+	log.Warn(msg)
+	mu.Lock()
+	defer mu.Unlock()
+	if collector == nil {
+		return
+	}
+	if len(log.Data) != 0 {
+		fields := make([]string, 0, len(log.Data))
+		for k, v := range log.Data {
+			fields = append(fields, fmt.Sprintf("%s=%v", k, v))
+		}
+		sort.Strings(fields)
+		msg += " (" + strings.Join(fields, ", ") + ")"
+	}
+	collector.warnings = append(collector.warnings, msg)
+}
+
+// Err returns error listing collected warnings. Returns nil if collector is not set or there are no warnings.
+func (c *Collector) Err() error {
+	if c == nil {
+		return nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(c.warnings) == 0 {
+		return nil
+	}
+	return fmt.Errorf("fail on warning: %s", strings.Join(c.warnings, "; "))
+}
@@ -0,0 +1,94 @@
+package yaml
+
+import (
+	"bytes"
+	"fmt"
+
+	kyaml "sigs.k8s.io/kustomize/kyaml/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// Quoting preferences for string values.
+const (
+	QuoteDouble = "double"
+	QuoteSingle = "single"
+)
+
+// Style - yaml output style. Zero value keeps Marshal output: 2 spaces indentation, sequences not indented
+// under parent key and strings quoted only when required.
+type Style struct {
+	// Indent - number of spaces of nested mapping indentation. Defaults to 2.
+	Indent int
+	// IndentSequences - indent sequence items by Indent spaces under parent key. Otherwise '- ' is a part of
+	// item indentation, so with 2 spaces indentation items are placed at parent key column.
+	IndentSequences bool
+	// Quote - quote all string values with QuoteDouble or QuoteSingle quotes. Mapping keys are not quoted.
+	Quote string
+}
+
+// MarshalStyle marshals object to yaml with given style.
+func MarshalStyle(object interface{}, style Style) ([]byte, error) {
+	if style == (Style{}) {
+		return yaml.Marshal(object)
+	}
+	// round trip through json tags to match Marshal field names and skip empty fields
+	jsonYaml, err := yaml.Marshal(object)
+	if err != nil {
+		return nil, err
+	}
+	var node kyaml.Node
+	if err = kyaml.Unmarshal(jsonYaml, &node); err != nil {
+		return nil, fmt.Errorf("%w: unable to parse yaml", err)
+	}
+	quoteStrings(&node, style.Quote)
+
+	var buf bytes.Buffer
+	encoder := kyaml.NewEncoder(&buf)
+	if style.Indent != 0 {
+		encoder.SetIndent(style.Indent)
+	}
+	if style.IndentSequences {
+		encoder.DefaultSeqIndent()
+	} else {
+		encoder.CompactSeqIndent()
+	}
+	if err = encoder.Encode(&node); err != nil {
+		return nil, err
+	}
+	if err = encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// quoteStrings sets quoting style of string scalar values of the node tree.
+func quoteStrings(node *kyaml.Node, quote string) {
+	var style kyaml.Style
+	switch quote {
+	case QuoteDouble:
+		style = kyaml.DoubleQuotedStyle
+	case QuoteSingle:
+		style = kyaml.SingleQuotedStyle
+	default:
+		return
+	}
+	var walk func(n *kyaml.Node)
+	walk = func(n *kyaml.Node) {
+		switch n.Kind {
+		case kyaml.ScalarNode:
+			if n.Tag == "!!str" {
+				n.Style = style
+			}
+		case kyaml.MappingNode:
+			// keys are at even positions
+			for i := 1; i < len(n.Content); i += 2 {
+				walk(n.Content[i])
+			}
+		default:
+			for _, c := range n.Content {
+				walk(c)
+			}
+		}
+	}
+	walk(node)
+}
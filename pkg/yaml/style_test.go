@@ -0,0 +1,53 @@
+package yaml
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalStyle(t *testing.T) {
+	values := map[string]interface{}{
+		"web": map[string]interface{}{
+			"replicas": int64(2),
+			"image":    map[string]interface{}{"tag": "1.25"},
+			"args":     []interface{}{"--debug", "--port=8080"},
+		},
+	}
+	t.Run("default", func(t *testing.T) {
+		res, err := MarshalStyle(values, Style{})
+		assert.NoError(t, err)
+		assert.Equal(t, `web:
+  args:
+  - --debug
+  - --port=8080
+  image:
+    tag: "1.25"
+  replicas: 2
+`, string(res))
+	})
+	t.Run("4 spaces indent", func(t *testing.T) {
+		res, err := MarshalStyle(values, Style{Indent: 4})
+		assert.NoError(t, err)
+		assert.Equal(t, `web:
+    args:
+      - --debug
+      - --port=8080
+    image:
+        tag: "1.25"
+    replicas: 2
+`, string(res))
+	})
+	t.Run("indented sequences and quotes", func(t *testing.T) {
+		res, err := MarshalStyle(values, Style{Indent: 4, IndentSequences: true, Quote: QuoteSingle})
+		assert.NoError(t, err)
+		assert.Equal(t, `web:
+    args:
+        - '--debug'
+        - '--port=8080'
+    image:
+        tag: '1.25'
+    replicas: 2
+`, string(res))
+	})
+}